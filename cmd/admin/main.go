@@ -0,0 +1,176 @@
+// Command admin — управление пользователями напрямую в БД, без HTTP API.
+// Нужен в первую очередь для создания первого администратора:
+//
+//	go run ./cmd/admin create-user -username admin -email admin@uni.edu -role admin
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"uniconnect/internal/admin"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/config"
+	"uniconnect/internal/database"
+)
+
+const usage = `usage: admin <command> [flags]
+
+commands:
+  create-user     -username NAME -email EMAIL [-password PASS] [-role ROLE]
+  set-role        -username NAME -role ROLE      (promote / demote)
+  reset-password  -username NAME [-password PASS]
+  list-users      [-q TEXT] [-role ROLE] [-banned] [-limit N]
+
+If -password is omitted, a random password is generated and printed.
+The database is taken from the usual configuration (DATABASE_URL or CONFIG_FILE).`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cmd, args := os.Args[1], os.Args[2:]
+	run, ok := map[string]func([]string) error{
+		"create-user":    createUser,
+		"set-role":       setRole,
+		"reset-password": resetPassword,
+		"list-users":     listUsers,
+	}[cmd]
+	if !ok {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := database.Connect(cfg.Database.URL); err != nil {
+		log.Fatal(err)
+	}
+	if err := run(args); err != nil {
+		log.Fatalf("%s: %v", cmd, err)
+	}
+}
+
+func createUser(args []string) error {
+	fs := flag.NewFlagSet("create-user", flag.ExitOnError)
+	username := fs.String("username", "", "username")
+	email := fs.String("email", "", "email")
+	password := fs.String("password", "", "password (generated if empty)")
+	role := fs.String("role", auth.RoleStudent, "role")
+	fs.Parse(args)
+
+	if *username == "" || *email == "" {
+		return fmt.Errorf("-username and -email are required")
+	}
+
+	pass, generated, err := passwordOrGenerate(*password)
+	if err != nil {
+		return err
+	}
+
+	id, err := admin.CreateUser(*username, *email, pass, *role)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created user %s (id=%d, role=%s)\n", *username, id, *role)
+	if generated {
+		fmt.Printf("password: %s\n", pass)
+	}
+	return nil
+}
+
+func setRole(args []string) error {
+	fs := flag.NewFlagSet("set-role", flag.ExitOnError)
+	username := fs.String("username", "", "username")
+	role := fs.String("role", "", "new role")
+	fs.Parse(args)
+
+	if *username == "" || *role == "" {
+		return fmt.Errorf("-username and -role are required")
+	}
+
+	id, err := admin.FindUserID(*username)
+	if err != nil {
+		return err
+	}
+	if err := admin.SetUserRole(id, *role); err != nil {
+		return err
+	}
+
+	fmt.Printf("user %s is now %s\n", *username, *role)
+	return nil
+}
+
+func resetPassword(args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	username := fs.String("username", "", "username")
+	password := fs.String("password", "", "new password (generated if empty)")
+	fs.Parse(args)
+
+	if *username == "" {
+		return fmt.Errorf("-username is required")
+	}
+
+	id, err := admin.FindUserID(*username)
+	if err != nil {
+		return err
+	}
+	pass, generated, err := passwordOrGenerate(*password)
+	if err != nil {
+		return err
+	}
+	if err := admin.SetPassword(id, pass); err != nil {
+		return err
+	}
+
+	fmt.Printf("password for %s reset, existing sessions revoked\n", *username)
+	if generated {
+		fmt.Printf("password: %s\n", pass)
+	}
+	return nil
+}
+
+func listUsers(args []string) error {
+	fs := flag.NewFlagSet("list-users", flag.ExitOnError)
+	q := fs.String("q", "", "search by username or email")
+	role := fs.String("role", "", "filter by role")
+	banned := fs.Bool("banned", false, "only banned users")
+	limit := fs.Int("limit", 100, "max rows")
+	fs.Parse(args)
+
+	users, err := admin.ListUsers(*q, *role, *banned, *limit, 0)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tROLE\t2FA\tBANNED")
+	for _, u := range users {
+		banned := "-"
+		if u.BannedAt != nil {
+			banned = "yes"
+			if u.BannedUntil != nil {
+				banned = "until " + u.BannedUntil.Format("2006-01-02 15:04")
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\t%s\n", u.ID, u.Username, u.Email, u.Role, u.TwoFactorEnabled, banned)
+	}
+	return w.Flush()
+}
+
+func passwordOrGenerate(password string) (string, bool, error) {
+	if password != "" {
+		return password, false, nil
+	}
+	generated, err := admin.GeneratePassword()
+	return generated, true, err
+}
@@ -0,0 +1,158 @@
+package main
+
+// Справочники для генерации. Порядок элементов важен: от него зависит результат при том же -seed.
+
+type faculty struct {
+	Code    string // часть email: <user>@<code>.seed.uni.edu
+	Name    string
+	Tags    []string
+	Courses []string
+	Groups  []string
+}
+
+var faculties = []faculty{
+	{
+		Code: "cs", Name: "Computer Science",
+		Tags:    []string{"programming", "algorithms", "ml"},
+		Courses: []string{"Algorithms", "Databases", "Operating Systems", "Machine Learning"},
+		Groups:  []string{"CS Students", "Competitive Programming Club"},
+	},
+	{
+		Code: "math", Name: "Mathematics",
+		Tags:    []string{"math", "proofs"},
+		Courses: []string{"Linear Algebra", "Calculus II", "Probability Theory"},
+		Groups:  []string{"Mathematics Students"},
+	},
+	{
+		Code: "phys", Name: "Physics",
+		Tags:    []string{"physics", "lab"},
+		Courses: []string{"Quantum Mechanics", "Thermodynamics", "Optics"},
+		Groups:  []string{"Physics Students", "Astronomy Society"},
+	},
+	{
+		Code: "econ", Name: "Economics",
+		Tags:    []string{"economics", "finance"},
+		Courses: []string{"Microeconomics", "Econometrics", "Corporate Finance"},
+		Groups:  []string{"Economics Students"},
+	},
+	{
+		Code: "law", Name: "Law",
+		Tags:    []string{"law", "moot-court"},
+		Courses: []string{"Civil Law", "Constitutional Law", "Criminal Procedure"},
+		Groups:  []string{"Law Students", "Debate Club"},
+	},
+	{
+		Code: "bio", Name: "Biology",
+		Tags:    []string{"biology", "genetics"},
+		Courses: []string{"Genetics", "Ecology", "Biochemistry"},
+		Groups:  []string{"Biology Students"},
+	},
+}
+
+var firstNames = []string{
+	"Anna", "Maria", "Elena", "Sofia", "Olga", "Daria", "Alina", "Polina", "Kate", "Emma",
+	"Ivan", "Alexey", "Dmitry", "Sergey", "Nikita", "Artem", "Maxim", "Pavel", "John", "Omar",
+}
+
+var lastNames = []string{
+	"Ivanov", "Petrov", "Sidorov", "Smirnov", "Kuznetsov", "Popov", "Volkov", "Sokolov",
+	"Lebedev", "Kozlov", "Novak", "Garcia", "Kim", "Nguyen", "Mueller", "Rossi",
+}
+
+// category — категория постов; {course}, {faculty} и {n} подставляются при генерации
+type category struct {
+	Name   string
+	Tags   []string
+	Titles []string
+}
+
+var categories = []category{
+	{
+		Name: "study",
+		Tags: []string{"exam", "notes", "homework"},
+		Titles: []string{
+			"Notes for {course}, lecture {n}",
+			"Looking for a study partner for {course}",
+			"Exam tips for {course}",
+			"Problem set {n} in {course}: stuck on the last task",
+		},
+	},
+	{
+		Name: "events",
+		Tags: []string{"meetup", "workshop"},
+		Titles: []string{
+			"{faculty} meetup this Friday",
+			"Guest lecture on {course}",
+			"Workshop: {course} in practice",
+		},
+	},
+	{
+		Name: "news",
+		Tags: []string{"announcement"},
+		Titles: []string{
+			"{faculty} department news, week {n}",
+			"Schedule change for {course}",
+		},
+	},
+	{
+		Name: "jobs",
+		Tags: []string{"internship", "career"},
+		Titles: []string{
+			"Teaching assistant wanted for {course}",
+			"Summer internship for {faculty} students",
+		},
+	},
+	{
+		Name: "housing",
+		Tags: []string{"dorm"},
+		Titles: []string{
+			"Room available near the {faculty} building",
+			"Looking for a flatmate, {faculty} student",
+		},
+	},
+	{
+		Name: "lost-found",
+		Tags: []string{"lost"},
+		Titles: []string{
+			"Lost a notebook after {course}",
+			"Found keys in auditorium {n}",
+		},
+	},
+}
+
+var sentences = []string{
+	"Any help is appreciated.",
+	"Details are in the comments.",
+	"Write me a private message if you are interested.",
+	"The room is on the third floor.",
+	"It starts at 6 pm, everyone is welcome.",
+	"I uploaded my notes to the shared folder.",
+	"The deadline is next Monday.",
+	"Coffee and snacks will be provided.",
+	"Please share with your groupmates.",
+	"Let me know if the link does not work.",
+}
+
+var commentTexts = []string{
+	"Thanks, very helpful!",
+	"I'm interested, sent you a message.",
+	"Is this still relevant?",
+	"Count me in.",
+	"Could you share the slides?",
+	"Same question here.",
+	"Great idea!",
+	"What time exactly?",
+	"I had the same problem last year, check the second chapter.",
+	"+1",
+}
+
+var messageTexts = []string{
+	"Hi! Are you going to the lecture tomorrow?",
+	"Can you send me the notes?",
+	"Sure, here they are.",
+	"Thanks!",
+	"Let's meet at the library at 5.",
+	"Did you finish the homework?",
+	"Not yet, the last task is hard.",
+	"See you there.",
+}
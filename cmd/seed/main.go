@@ -0,0 +1,474 @@
+// Command seed — тестовые данные для разработки: пользователи разных факультетов,
+// посты с тегами, комментарии, лайки, сохранения, группы и личные сообщения.
+//
+//	go run ./cmd/seed
+//	go run ./cmd/seed -users 200 -posts 1000 -seed 7
+//
+// Набор данных зависит только от флагов: повторный запуск с теми же флагами ничего
+// не дублирует. -reset удаляет всё, что было создано seed (пользователей
+// *.seed.uni.edu и их контент), например перед запуском с другим -seed.
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"uniconnect/internal/auth"
+	"uniconnect/internal/config"
+	"uniconnect/internal/database"
+
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const emailDomain = "seed.uni.edu"
+
+type options struct {
+	Seed     uint64
+	Users    int
+	Posts    int
+	Comments int
+	Likes    int
+	Saves    int
+	Messages int
+}
+
+func main() {
+	log.SetFlags(0)
+	configPath := flag.String("config", "", "path to YAML config (default: $CONFIG_FILE)")
+	var opts options
+	flag.Uint64Var(&opts.Seed, "seed", 1, "random seed; the same seed gives the same data")
+	flag.IntVar(&opts.Users, "users", 60, "number of users")
+	flag.IntVar(&opts.Posts, "posts", 200, "number of posts")
+	flag.IntVar(&opts.Comments, "comments", 600, "number of comments")
+	flag.IntVar(&opts.Likes, "likes", 1500, "number of likes")
+	flag.IntVar(&opts.Saves, "saves", 400, "number of saves")
+	flag.IntVar(&opts.Messages, "messages", 300, "number of private messages")
+	password := flag.String("password", "password", "password for every seeded user")
+	reset := flag.Bool("reset", false, "delete previously seeded data before seeding")
+	resetOnly := flag.Bool("reset-only", false, "only delete previously seeded data")
+	flag.Parse()
+
+	if opts.Users < 2 {
+		log.Fatal("-users must be at least 2")
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := database.Connect(cfg.Database.URL); err != nil {
+		log.Fatal(err)
+	}
+	defer database.Close()
+
+	if *reset || *resetOnly {
+		n, err := resetSeed(database.DB)
+		if err != nil {
+			log.Fatalf("reset: %v", err)
+		}
+		fmt.Printf("removed %d seeded users and their content\n", n)
+		if *resetOnly {
+			return
+		}
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	data := generate(opts)
+	stats, err := apply(database.DB, data, string(hashed), time.Now())
+	if err != nil {
+		log.Fatalf("seed: %v", err)
+	}
+	fmt.Println(stats)
+	fmt.Printf("log in as any seeded user, e.g. %s / %s\n", data.Users[0].Username, *password)
+}
+
+// ───────────────────────────────
+// Генерация (без БД, только из rng)
+// ───────────────────────────────
+
+type dataset struct {
+	Users    []seedUser
+	Posts    []seedPost
+	Comments []seedComment
+	Likes    []postUser
+	Saves    []postUser
+	Groups   []seedGroup
+	Messages []seedMessage
+}
+
+type seedUser struct {
+	Username string
+	Email    string
+	Role     string
+	Faculty  int
+}
+
+// ссылки на пользователей и посты — индексы в dataset
+type seedPost struct {
+	Author   int
+	Title    string
+	Content  string
+	Category string
+	Tags     []string
+	Age      time.Duration
+}
+
+type seedComment struct {
+	Post    int
+	Author  int
+	Content string
+	Age     time.Duration
+}
+
+type postUser struct{ Post, User int }
+
+type seedGroup struct {
+	Name     string
+	Members  []int
+	Requests []int
+}
+
+type seedMessage struct {
+	Sender   int
+	Receiver int
+	Content  string
+	Age      time.Duration
+}
+
+const maxAge = 30 * 24 * time.Hour
+
+func generate(opts options) dataset {
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x5eed))
+	pick := func(list []string) string { return list[rng.IntN(len(list))] }
+	// skewed — индекс со смещением к началу: у первых постов больше лайков (для ленты trending)
+	skewed := func(n int) int { return int(math.Pow(rng.Float64(), 2.5) * float64(n)) }
+
+	var d dataset
+
+	byFaculty := make([][]int, len(faculties))
+	for i := 0; i < opts.Users; i++ {
+		f := rng.IntN(len(faculties))
+		first, last := pick(firstNames), pick(lastNames)
+		role := auth.RoleStudent
+		switch {
+		case i == 0:
+			role = auth.RoleModerator
+		case rng.IntN(10) == 0:
+			role = auth.RoleTeacher
+		}
+		username := fmt.Sprintf("%s.%s%d", strings.ToLower(first), strings.ToLower(last), i+1)
+		d.Users = append(d.Users, seedUser{
+			Username: username,
+			Email:    fmt.Sprintf("%s@%s.%s", username, faculties[f].Code, emailDomain),
+			Role:     role,
+			Faculty:  f,
+		})
+		byFaculty[f] = append(byFaculty[f], i)
+	}
+
+	for i := 0; i < opts.Posts; i++ {
+		author := rng.IntN(len(d.Users))
+		fac := faculties[d.Users[author].Faculty]
+		cat := categories[rng.IntN(len(categories))]
+		title := strings.NewReplacer(
+			"{course}", pick(fac.Courses),
+			"{faculty}", fac.Name,
+			"{n}", strconv.Itoa(rng.IntN(12)+1),
+		).Replace(pick(cat.Titles))
+
+		content := pick(sentences) + " " + pick(sentences)
+		tags := []string{pick(cat.Tags), pick(fac.Tags)}
+		if rng.IntN(3) == 0 {
+			tags = append(tags, pick(faculties[rng.IntN(len(faculties))].Tags))
+		}
+
+		d.Posts = append(d.Posts, seedPost{
+			Author:   author,
+			Title:    title,
+			Content:  content,
+			Category: cat.Name,
+			Tags:     tags,
+			Age:      time.Duration(rng.Int64N(int64(maxAge))),
+		})
+	}
+
+	if len(d.Posts) > 0 {
+		for i := 0; i < opts.Comments; i++ {
+			post := skewed(len(d.Posts))
+			d.Comments = append(d.Comments, seedComment{
+				Post:    post,
+				Author:  rng.IntN(len(d.Users)),
+				Content: pick(commentTexts),
+				Age:     time.Duration(rng.Float64() * float64(d.Posts[post].Age)),
+			})
+		}
+		for i := 0; i < opts.Likes; i++ {
+			d.Likes = append(d.Likes, postUser{Post: skewed(len(d.Posts)), User: rng.IntN(len(d.Users))})
+		}
+		for i := 0; i < opts.Saves; i++ {
+			d.Saves = append(d.Saves, postUser{Post: skewed(len(d.Posts)), User: rng.IntN(len(d.Users))})
+		}
+	}
+
+	// группы факультета: большинство студентов состоит, часть подала заявку
+	for f, fac := range faculties {
+		for _, name := range fac.Groups {
+			g := seedGroup{Name: name}
+			for _, u := range byFaculty[f] {
+				switch rng.IntN(4) {
+				case 0, 1:
+					g.Members = append(g.Members, u)
+				case 2:
+					g.Requests = append(g.Requests, u)
+				}
+			}
+			d.Groups = append(d.Groups, g)
+		}
+	}
+
+	// переписка в основном внутри факультета
+	for i := 0; i < opts.Messages; i++ {
+		sender := rng.IntN(len(d.Users))
+		receiver := rng.IntN(len(d.Users))
+		if peers := byFaculty[d.Users[sender].Faculty]; rng.IntN(4) != 0 && len(peers) > 1 {
+			receiver = peers[rng.IntN(len(peers))]
+		}
+		if receiver == sender {
+			receiver = (sender + 1) % len(d.Users)
+		}
+		d.Messages = append(d.Messages, seedMessage{
+			Sender:   sender,
+			Receiver: receiver,
+			Content:  pick(messageTexts),
+			Age:      time.Duration(rng.Int64N(int64(maxAge))),
+		})
+	}
+
+	return d
+}
+
+// ───────────────────────────────
+// Запись в БД
+// ───────────────────────────────
+
+// stats — сколько строк добавлено в этот запуск (уже существующие не считаются)
+type stats struct {
+	Users, Posts, Comments, Likes, Saves, Groups, Members, Requests, Messages int64
+}
+
+func (s stats) String() string {
+	return fmt.Sprintf("added: %d users, %d posts, %d comments, %d likes, %d saves, %d groups, %d group members, %d join requests, %d messages",
+		s.Users, s.Posts, s.Comments, s.Likes, s.Saves, s.Groups, s.Members, s.Requests, s.Messages)
+}
+
+// apply записывает набор в одной транзакции. Каждая строка ищется по естественному ключу
+// (username, автор+заголовок, ...) и вставляется только если её ещё нет.
+func apply(db *sqlx.DB, d dataset, passwordHash string, now time.Time) (stats, error) {
+	var s stats
+	tx, err := db.Beginx()
+	if err != nil {
+		return s, err
+	}
+	defer tx.Rollback()
+
+	userIDs := make([]int, len(d.Users))
+	for i, u := range d.Users {
+		var inserted bool
+		err := tx.QueryRowx(`
+			INSERT INTO users (username, email, password, role) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (username) DO UPDATE SET username = EXCLUDED.username
+			RETURNING id, xmax = 0
+		`, u.Username, u.Email, passwordHash, u.Role).Scan(&userIDs[i], &inserted)
+		if err != nil {
+			return s, fmt.Errorf("user %s: %w", u.Username, err)
+		}
+		if inserted {
+			s.Users++
+		}
+	}
+
+	tagIDs := map[string]int{}
+	postIDs := make([]int, len(d.Posts))
+	for i, p := range d.Posts {
+		created := now.Add(-p.Age)
+		id, inserted, err := findOrInsert(tx,
+			`SELECT id FROM posts WHERE author_id=$1 AND title=$2`, []interface{}{userIDs[p.Author], p.Title},
+			`INSERT INTO posts (author_id, title, content, category, created_at, updated_at)
+			 VALUES ($1, $2, $3, $4, $5, $5) RETURNING id`,
+			userIDs[p.Author], p.Title, p.Content, p.Category, created,
+		)
+		if err != nil {
+			return s, fmt.Errorf("post %q: %w", p.Title, err)
+		}
+		postIDs[i] = id
+		if !inserted {
+			continue
+		}
+		s.Posts++
+
+		for _, tag := range p.Tags {
+			if _, ok := tagIDs[tag]; !ok {
+				var tagID int
+				err := tx.Get(&tagID, `
+					INSERT INTO tags (name) VALUES ($1)
+					ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+					RETURNING id
+				`, tag)
+				if err != nil {
+					return s, fmt.Errorf("tag %s: %w", tag, err)
+				}
+				tagIDs[tag] = tagID
+			}
+			if _, err := tx.Exec(`INSERT INTO post_tags (post_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, id, tagIDs[tag]); err != nil {
+				return s, err
+			}
+		}
+	}
+
+	for _, cm := range d.Comments {
+		n, err := insertMissing(tx, `
+			INSERT INTO comments (post_id, author_id, content, created_at)
+			SELECT $1, $2, $3, $4
+			WHERE NOT EXISTS (SELECT 1 FROM comments WHERE post_id=$1 AND author_id=$2 AND content=$3)
+		`, postIDs[cm.Post], userIDs[cm.Author], cm.Content, now.Add(-cm.Age))
+		if err != nil {
+			return s, fmt.Errorf("comment: %w", err)
+		}
+		s.Comments += n
+	}
+
+	for _, l := range d.Likes {
+		n, err := insertMissing(tx, `INSERT INTO post_likes (post_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			postIDs[l.Post], userIDs[l.User])
+		if err != nil {
+			return s, fmt.Errorf("like: %w", err)
+		}
+		s.Likes += n
+	}
+	for _, sv := range d.Saves {
+		n, err := insertMissing(tx, `INSERT INTO post_saves (post_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			postIDs[sv.Post], userIDs[sv.User])
+		if err != nil {
+			return s, fmt.Errorf("save: %w", err)
+		}
+		s.Saves += n
+	}
+
+	for _, g := range d.Groups {
+		groupID, inserted, err := findOrInsert(tx,
+			`SELECT id FROM groups WHERE name=$1`, []interface{}{g.Name},
+			`INSERT INTO groups (name, seeded) VALUES ($1, true) RETURNING id`, g.Name,
+		)
+		if err != nil {
+			return s, fmt.Errorf("group %s: %w", g.Name, err)
+		}
+		if inserted {
+			s.Groups++
+		}
+		for _, u := range g.Members {
+			n, err := insertMissing(tx, `INSERT INTO group_members (group_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+				groupID, userIDs[u])
+			if err != nil {
+				return s, err
+			}
+			s.Members += n
+		}
+		for _, u := range g.Requests {
+			n, err := insertMissing(tx, `
+				INSERT INTO group_join_requests (group_id, user_id)
+				SELECT $1, $2
+				WHERE NOT EXISTS (SELECT 1 FROM group_members WHERE group_id=$1 AND user_id=$2)
+				ON CONFLICT DO NOTHING
+			`, groupID, userIDs[u])
+			if err != nil {
+				return s, err
+			}
+			s.Requests += n
+		}
+	}
+
+	for _, m := range d.Messages {
+		n, err := insertMissing(tx, `
+			INSERT INTO messages (sender_id, receiver_id, content, created_at)
+			SELECT $1, $2, $3, $4
+			WHERE NOT EXISTS (SELECT 1 FROM messages WHERE sender_id=$1 AND receiver_id=$2 AND content=$3)
+		`, userIDs[m.Sender], userIDs[m.Receiver], m.Content, now.Add(-m.Age))
+		if err != nil {
+			return s, fmt.Errorf("message: %w", err)
+		}
+		s.Messages += n
+	}
+
+	return s, tx.Commit()
+}
+
+// findOrInsert возвращает ID существующей строки или вставляет новую; true — вставлена
+func findOrInsert(tx *sqlx.Tx, find string, findArgs []interface{}, insert string, insertArgs ...interface{}) (int, bool, error) {
+	var id int
+	err := tx.Get(&id, find, findArgs...)
+	if err == nil {
+		return id, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+	if err := tx.Get(&id, insert, insertArgs...); err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+func insertMissing(tx *sqlx.Tx, query string, args ...interface{}) (int64, error) {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// resetSeed удаляет пользователей seed вместе с их контентом и группы, созданные seed (groups.seeded);
+// одноимённые группы, созданные пользователями, остаются
+func resetSeed(db *sqlx.DB) (int64, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	const seeded = `SELECT id FROM users WHERE email LIKE '%@%.` + emailDomain + `'`
+	// комментарии и сообщения не удаляются каскадно (ON DELETE SET NULL)
+	stmts := []string{
+		`DELETE FROM comments WHERE author_id IN (` + seeded + `)`,
+		`DELETE FROM messages WHERE sender_id IN (` + seeded + `) OR receiver_id IN (` + seeded + `)`,
+	}
+	for _, q := range stmts {
+		if _, err := tx.Exec(q); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM groups WHERE seeded`); err != nil {
+		return 0, err
+	}
+
+	// посты, лайки, сохранения, теги постов и членство в группах удаляются каскадно
+	res, err := tx.Exec(`DELETE FROM users WHERE email LIKE '%@%.` + emailDomain + `'`)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return n, tx.Commit()
+}
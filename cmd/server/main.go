@@ -1,140 +1,172 @@
-package main
-
-import (
-	"log"
-	"net/http"
-
-	"uniconnect/internal/auth"
-	"uniconnect/internal/database"
-	"uniconnect/internal/groups"
-	"uniconnect/internal/messages"
-	"uniconnect/internal/posts"
-	"uniconnect/internal/redis"
-	"uniconnect/internal/websocket"
-
-	"github.com/gin-gonic/gin"
-)
-
-func main() {
-	// DB
-	if err := database.Connect(); err != nil {
-		log.Fatal(err)
-	}
-
-	// Redis
-	redis.Connect("uniconnect-redis", "", 6379)
-
-	// Gin
-	r := gin.Default()
-
-	// ───────────────────────────────
-	// API ROOT
-	// ───────────────────────────────
-	api := r.Group("/api")
-
-	// setup (создание админа по токену)
-	// UPDATE users SET role = 'admin' WHERE username = 'admin';
-
-	// ───────────────────────────────
-	// AUTH
-	// ───────────────────────────────
-	authRoutes := api.Group("/auth")
-	{
-		authRoutes.POST("/register", auth.RegisterHandler)
-		authRoutes.POST("/login", auth.LoginHandler)
-
-		authRoutes.GET("/register", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"message": "Use POST /api/auth/register with JSON body: {username, password, email}",
-			})
-		})
-
-		authRoutes.GET("/login", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"message": "Use POST /api/auth/login with JSON body: {username, password}",
-			})
-		})
-
-		authRoutes.GET("/profile", auth.AuthMiddleware(""), auth.ProfileHandler)
-	}
-
-	// ───────────────────────────────
-	// ADMIN
-	// ───────────────────────────────
-	adminRoutes := api.Group("/admin")
-	adminRoutes.Use(auth.AuthMiddleware("admin"))
-	{
-		adminRoutes.GET("/dashboard", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{"message": "Welcome admin"})
-		})
-	}
-
-	// ───────────────────────────────
-	// POSTS
-	// ───────────────────────────────
-	postRoutes := api.Group("/posts")
-	postRoutes.Use(auth.AuthMiddleware("")) // любой авторизованный
-	{
-		postRoutes.POST("/", posts.CreatePostHandler)
-		postRoutes.GET("/", posts.ListPostsHandler)
-		postRoutes.PUT("/:id", posts.UpdatePostHandler)
-		postRoutes.DELETE("/:id", posts.DeletePostHandler)
-		postRoutes.GET("/search", posts.SearchPosts)
-
-		// Likes
-		postRoutes.POST("/:id/like", posts.LikePostHandler)
-		postRoutes.DELETE("/:id/like", posts.UnlikePostHandler)
-		postRoutes.GET("/liked", posts.ListLikedPostsHandler)
-
-		// Saves
-		postRoutes.POST("/:id/save", posts.SavePostHandler)
-		postRoutes.DELETE("/:id/save", posts.UnsavePostHandler)
-	}
-
-	// ───────────────────────────────
-	// COMMENTS
-	// ───────────────────────────────
-	commentRoutes := api.Group("/posts/commentary")
-	commentRoutes.Use(auth.AuthMiddleware(""))
-	{
-		commentRoutes.POST("/:postId", posts.CreateCommentHandler)
-		commentRoutes.GET("/:postId", posts.ListCommentsHandler)
-	}
-
-	// ───────────────────────────────
-	// MESSAGES (личные сообщения)
-	// ───────────────────────────────
-	messageRoutes := api.Group("/messages")
-	messageRoutes.Use(auth.AuthMiddleware(""))
-	{
-		messageRoutes.POST("/:chatId", messages.SendMessageHandler)
-		messageRoutes.GET("/:chatId", messages.ListMessagesHandler)
-	}
-
-	// ───────────────────────────────
-	// GROUPS (группы/чат-группы и заявки)
-	// ───────────────────────────────
-	groupRoutes := api.Group("/groups")
-	groupRoutes.Use(auth.AuthMiddleware(""))
-	{
-		groupRoutes.POST("/:groupId/join", groups.RequestJoinHandler) // студент — запрос на вступление
-		groupRoutes.GET("/", groups.ListGroupsHandler)                // список групп (доступно всем авторизованным)
-	}
-
-	// Admin: управление группами и заявками
-	adminRoutes.POST("/groups", groups.CreateGroupHandler)                             // создать группу
-	adminRoutes.GET("/groups", groups.ListGroupsHandler)                               // список всех групп
-	adminRoutes.GET("/groups/requests", groups.ListJoinRequestsHandler)                // список заявок
-	adminRoutes.POST("/groups/requests/:id/approve", groups.ApproveJoinRequestHandler) // подтвердить заявку
-	adminRoutes.DELETE("/groups/requests/:id", groups.RemoveJoinRequestHandler)        // удалить/отклонить заявку
-
-	// ───────────────────────────────
-	// WEBSOCKETS
-	// ───────────────────────────────
-	r.GET("/ws/comments/:postId", websocket.CommentsWS)
-	r.GET("/ws/private/:chatId", websocket.PrivateWS)
-
-	// ───────────────────────────────
-
-	r.Run(":8080")
-}
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"syscall"
+	"time"
+
+	"uniconnect/internal/account"
+	"uniconnect/internal/admin"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/config"
+	"uniconnect/internal/database"
+	"uniconnect/internal/health"
+	"uniconnect/internal/idempotency"
+	"uniconnect/internal/logging"
+	"uniconnect/internal/metrics"
+	"uniconnect/internal/migrator"
+	"uniconnect/internal/openapi"
+	"uniconnect/internal/ratelimit"
+	"uniconnect/internal/redis"
+	"uniconnect/internal/tracing"
+	"uniconnect/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	configPath := flag.String("config", "", "path to YAML config (default: $CONFIG_FILE)")
+	checkRoutes := flag.Bool("check-routes", false, "check that every route is described in the OpenAPI spec and exit")
+	flag.Parse()
+
+	// проверка для CI: маршруты собираются без подключения к БД
+	if *checkRoutes {
+		gin.SetMode(gin.ReleaseMode)
+		if err := checkSpec(newRouter(newHandlers(nil, nil), time.Time{})); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d operations documented\n", len(openapi.Operations()))
+		return
+	}
+
+	// Config
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := logging.Setup(cfg.Log); err != nil {
+		log.Fatal(err)
+	}
+
+	// Tracing (OpenTelemetry), включается через tracing.exporter
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// DB
+	if err := database.Connect(cfg.Database.URL); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Database.AutoMigrate {
+		if err := migrator.Up(cfg.Database.URL, cfg.Database.MigrationsDir); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// JWT keys
+	if err := auth.Configure(cfg.Auth); err != nil {
+		log.Fatal(err)
+	}
+
+	// Redis
+	redis.Connect(cfg.Redis.Host, cfg.Redis.Password, cfg.Redis.Port)
+
+	// SSO (OIDC), включается через oidc.issuer_url
+	if err := auth.InitOIDC(context.Background(), cfg.OIDC); err != nil {
+		log.Fatal(err)
+	}
+
+	account.Configure(cfg.Account)
+	admin.Configure(cfg.RateLimit)
+	idempotency.Configure(cfg.HTTP.IdempotencyTTL)
+	health.Configure(cfg.Database.MigrationsDir)
+	websocket.Configure(cfg.HTTP)
+
+	metrics.RegisterDB(database.DB)
+	metrics.RegisterRedis(redis.Rdb)
+
+	// лимиты запросов по группам маршрутов (Redis)
+	var limits map[string]ratelimit.Rule
+	if cfg.RateLimit.Enabled {
+		if limits, err = ratelimit.ParseRules(cfg.RateLimit.Rules); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	r := newRouter(newHandlers(database.DB, limits), cfg.HTTP.LegacySunset())
+	// пробелы в документации не мешают работе сервера; сборку останавливают тест и -check-routes
+	if err := checkSpec(r); err != nil {
+		slog.Warn("routes missing from openapi.yaml", "error", err)
+	}
+
+	srv := &http.Server{Addr: cfg.HTTP.Addr, Handler: r}
+	serve(srv, cfg.HTTP.ShutdownTimeout, shutdownTracing)
+}
+
+// serve запускает сервер и на SIGINT/SIGTERM останавливает его:
+// новые подключения не принимаются, текущие запросы дорабатывают до timeout,
+// WebSocket-клиенты получают close frame, затем закрываются БД и Redis
+// и отправляются оставшиеся спаны.
+func serve(srv *http.Server, timeout time.Duration, shutdownTracing func(context.Context) error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("HTTP server listening", "addr", srv.Addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	case <-ctx.Done():
+		stop() // повторный Ctrl+C завершит процесс сразу
+		slog.Info("shutting down, draining connections", "timeout", timeout.String())
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("HTTP shutdown", "error", err)
+	}
+	// hijacked-соединения (WebSocket) Shutdown не ждёт — закрываем их сами
+	websocket.CloseAll()
+
+	if err := database.Close(); err != nil {
+		slog.Error("database close", "error", err)
+	}
+	if err := redis.Close(); err != nil {
+		slog.Error("redis close", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("tracing shutdown", "error", err)
+	}
+	slog.Info("server stopped")
+}
+
+// recoverPanic — паника в обработчике: пишем стек в лог, клиенту отдаём 500
+func recoverPanic(c *gin.Context, recovered any) {
+	err := fmt.Errorf("panic: %v", recovered)
+	logging.FromContext(c).Error("panic recovered",
+		"error", err.Error(),
+		"route", c.FullPath(),
+		"user_id", c.GetInt("user_id"),
+		"stack", string(debug.Stack()),
+	)
+	c.Error(err)
+	apperr.Render(c, apperr.Internal(err))
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"uniconnect/internal/account"
+	"uniconnect/internal/admin"
+	"uniconnect/internal/apiversion"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/groups"
+	"uniconnect/internal/health"
+	"uniconnect/internal/idempotency"
+	"uniconnect/internal/logging"
+	"uniconnect/internal/messages"
+	"uniconnect/internal/metrics"
+	"uniconnect/internal/openapi"
+	"uniconnect/internal/posts"
+	"uniconnect/internal/ratelimit"
+	"uniconnect/internal/repository/postgres"
+	"uniconnect/internal/tracing"
+	"uniconnect/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// handlers — обработчики с их репозиториями и лимиты запросов по группам маршрутов
+type handlers struct {
+	auth     *auth.Handler
+	posts    *posts.Handler
+	messages *messages.Handler
+	groups   *groups.Handler
+	ws       *websocket.Handler
+	limits   map[string]ratelimit.Rule
+}
+
+// newHandlers собирает обработчики поверх Postgres; к БД при этом не обращается
+func newHandlers(db *sqlx.DB, limits map[string]ratelimit.Rule) handlers {
+	commentRepo := postgres.NewCommentRepo(db)
+	messageRepo := postgres.NewMessageRepo(db)
+	postRepo := postgres.NewPostRepo(db)
+	ws := websocket.NewHandler(commentRepo, messageRepo)
+	ws.FrameLimit = limits["ws"]
+	return handlers{
+		auth:     auth.NewHandler(postgres.NewUserRepo(db)),
+		posts:    posts.NewHandler(postRepo, commentRepo, postgres.NewLikeRepo(db)),
+		messages: messages.NewHandler(messageRepo),
+		groups:   groups.NewHandler(postgres.NewGroupRepo(db)),
+		ws:       ws,
+		limits:   limits,
+	}
+}
+
+// limit — лимит запросов группы маршрутов из rate_limit.rules; без правила — без ограничений
+func (h handlers) limit(group string) gin.HandlerFunc {
+	return ratelimit.Middleware(h.limits[group])
+}
+
+// /api/... без версии — прежние адреса v1; устарели с legacyDeprecatedSince
+const (
+	legacyPrefix    = "/api"
+	legacySuccessor = "/api/v1"
+)
+
+var legacyDeprecatedSince = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// apiVersions — версии API, каждая под /api/<name>. Несовместимое изменение —
+// новая версия рядом со старой, например:
+//
+//	v2 := v1 с заменой h.commentRoutes на h.commentRoutesV2 (/posts/:id/comments)
+//
+// Клиенты v1 продолжают работать, пока версию не выведут из списка.
+func (h handlers) apiVersions() []apiversion.Version {
+	v1 := apiversion.Version{Name: "v1", Routes: []apiversion.Routes{
+		h.authRoutes,
+		h.userRoutes,
+		h.adminRoutes,
+		h.postRoutes,
+		h.commentRoutes,
+		h.messageRoutes,
+		h.groupRoutes,
+	}}
+	return []apiversion.Version{v1}
+}
+
+// newRouter — Gin со всеми middleware и маршрутами; legacySunset — дата отключения
+// маршрутов /api/... без версии (нулевая — не назначена)
+func newRouter(h handlers, legacySunset time.Time) *gin.Engine {
+	// Gin: свои логи (JSON, с request_id) вместо стандартного текстового логгера
+	r := gin.New()
+	r.Use(
+		logging.RequestID(),
+		tracing.Middleware(),
+		logging.Middleware(),
+		metrics.Middleware(), // снаружи recovery: запросы с паникой считаются как 500
+		gin.CustomRecoveryWithWriter(io.Discard, recoverPanic),
+		apperr.Middleware(),
+	)
+	r.NoRoute(func(c *gin.Context) { apperr.Abort(c, apperr.NotFound("route not found")) })
+
+	// проверки для оркестрации (docker-compose healthcheck, k8s probes)
+	r.GET("/healthz", health.LivenessHandler)
+	r.GET("/readyz", health.ReadinessHandler)
+	r.GET("/version", health.VersionHandler)
+	r.GET("/metrics", metrics.Handler())
+
+	// публичные ключи для проверки наших токенов другими сервисами
+	r.GET("/.well-known/jwks.json", auth.JWKSHandler)
+
+	// ───────────────────────────────
+	// API ROOT
+	// ───────────────────────────────
+	api := r.Group("/api")
+
+	// документация: спецификация OpenAPI и Swagger UI (общие для всех версий)
+	api.GET("/openapi.json", openapi.JSONHandler)
+	api.GET("/docs", openapi.UIHandler)
+
+	// первый админ создаётся через CLI:
+	// go run ./cmd/admin create-user -username admin -email admin@uni.edu -role admin
+
+	// /api/v1, /api/v2, ...
+	versions := h.apiVersions()
+	apiversion.Register(api, versions...)
+
+	// прежние адреса без версии — те же маршруты v1 для уже установленных клиентов,
+	// с заголовками Deprecation/Sunset/Link
+	legacy := api.Group("", apiversion.Deprecated(apiversion.Deprecation{
+		Since:  legacyDeprecatedSince,
+		Sunset: legacySunset,
+		From:   legacyPrefix,
+		To:     legacySuccessor,
+		Docs:   "/api/docs",
+	}))
+	for _, routes := range versions[0].Routes {
+		routes(legacy)
+	}
+
+	// ───────────────────────────────
+	// WEBSOCKETS
+	// ───────────────────────────────
+	// токен — в заголовке или в ?access_token= (браузерам заголовки недоступны)
+	ws := r.Group("/ws", websocket.QueryToken(), auth.AuthMiddleware(""))
+	ws.GET("/comments/:postId", auth.APIScope("comments"), h.ws.CommentsWS)
+	ws.GET("/private/:chatId", auth.APIScope("messages"), h.ws.PrivateWS)
+
+	return r
+}
+
+// ───────────────────────────────
+// AUTH
+// ───────────────────────────────
+func (h handlers) authRoutes(api *gin.RouterGroup) {
+	authRoutes := api.Group("/auth", h.limit("auth")) // по IP, до авторизации
+	{
+		authRoutes.POST("/register", h.auth.Register)
+		authRoutes.POST("/login", h.auth.Login)
+		authRoutes.POST("/login/2fa", auth.LoginTwoFactorHandler) // второй шаг входа по challenge_token
+		authRoutes.GET("/oidc/login", auth.OIDCLoginHandler)      // вход через университетский SSO
+		authRoutes.GET("/oidc/callback", auth.OIDCCallbackHandler)
+
+		authRoutes.GET("/profile", auth.AuthMiddleware(""), auth.APIScope("profile"), h.auth.Profile)
+
+		// сессии: продление токена, список устройств, выход
+		sessionRoutes := authRoutes.Group("")
+		sessionRoutes.Use(auth.AuthMiddleware(""), auth.APIScope("profile"))
+		{
+			sessionRoutes.POST("/refresh", auth.RefreshHandler)
+			sessionRoutes.POST("/logout", auth.LogoutHandler)
+			sessionRoutes.GET("/sessions", auth.ListSessionsHandler)
+			sessionRoutes.DELETE("/sessions/:id", auth.RevokeSessionHandler)
+		}
+
+		// двухфакторная аутентификация (TOTP)
+		twoFactor := authRoutes.Group("/2fa")
+		twoFactor.Use(auth.AuthMiddleware(""), auth.APIScope("profile"))
+		{
+			twoFactor.POST("/setup", auth.TwoFactorSetupHandler)
+			twoFactor.POST("/enable", auth.TwoFactorEnableHandler)
+			twoFactor.POST("/disable", auth.TwoFactorDisableHandler)
+			twoFactor.POST("/recovery-codes", auth.RegenerateRecoveryCodesHandler)
+		}
+	}
+}
+
+// ───────────────────────────────
+// USERS (персональные данные)
+// ───────────────────────────────
+func (h handlers) userRoutes(api *gin.RouterGroup) {
+	userRoutes := api.Group("/users")
+	userRoutes.Use(auth.AuthMiddleware(""), auth.APIScope("profile"), h.limit("users"))
+	{
+		userRoutes.GET("/me/export", account.ExportHandler) // выгрузка всех данных пользователя
+		userRoutes.DELETE("/me", account.DeleteAccountHandler)
+	}
+}
+
+// ───────────────────────────────
+// ADMIN
+// ───────────────────────────────
+func (h handlers) adminRoutes(api *gin.RouterGroup) {
+	adminRoutes := api.Group("/admin")
+	adminRoutes.Use(auth.AuthMiddleware(""), auth.APIScope("admin"), h.limit("admin")) // доступ к конкретным маршрутам — по правам роли
+	{
+		adminRoutes.GET("/dashboard", auth.RequirePermission(auth.PermAdminDashboard), admin.DashboardHandler)
+
+		// роли и права
+		adminRoutes.GET("/roles", auth.RequirePermission(auth.PermUserRoleAssign), admin.ListRolesHandler)
+		adminRoutes.PUT("/users/:id/role", auth.RequirePermission(auth.PermUserRoleAssign), admin.SetUserRoleHandler)
+
+		// управление пользователями
+		adminRoutes.GET("/users", auth.RequirePermission(auth.PermUserView), admin.ListUsersHandler)
+		adminRoutes.GET("/users/:id", auth.RequirePermission(auth.PermUserView), admin.GetUserHandler)
+		adminRoutes.POST("/users/:id/ban", auth.RequirePermission(auth.PermUserBan), admin.BanUserHandler)
+		adminRoutes.DELETE("/users/:id/ban", auth.RequirePermission(auth.PermUserBan), admin.UnbanUserHandler)
+		adminRoutes.POST("/users/:id/logout", auth.RequirePermission(auth.PermUserManage), admin.ForceLogoutHandler)
+		adminRoutes.POST("/users/:id/reset-password", auth.RequirePermission(auth.PermUserManage), admin.ResetPasswordHandler)
+
+		// сервисные аккаунты и API-ключи
+		adminRoutes.POST("/service-accounts", auth.RequirePermission(auth.PermServiceAccountManage), admin.CreateServiceAccountHandler)
+		adminRoutes.GET("/service-accounts", auth.RequirePermission(auth.PermServiceAccountManage), admin.ListServiceAccountsHandler)
+		adminRoutes.POST("/service-accounts/:id/keys", auth.RequirePermission(auth.PermServiceAccountManage), admin.CreateAPIKeyHandler)
+		adminRoutes.GET("/api-keys", auth.RequirePermission(auth.PermServiceAccountManage), admin.ListAPIKeysHandler)
+		adminRoutes.DELETE("/api-keys/:id", auth.RequirePermission(auth.PermServiceAccountManage), admin.RevokeAPIKeyHandler)
+
+		// управление группами и заявками
+		adminRoutes.POST("/groups", auth.RequirePermission(auth.PermGroupCreate), h.groups.CreateGroup)                              // создать группу
+		adminRoutes.GET("/groups", auth.RequirePermission(auth.PermGroupApprove), h.groups.ListGroups)                               // список всех групп
+		adminRoutes.GET("/groups/requests", auth.RequirePermission(auth.PermGroupApprove), h.groups.ListJoinRequests)                // список заявок
+		adminRoutes.POST("/groups/requests/:id/approve", auth.RequirePermission(auth.PermGroupApprove), h.groups.ApproveJoinRequest) // подтвердить заявку
+		adminRoutes.DELETE("/groups/requests/:id", auth.RequirePermission(auth.PermGroupApprove), h.groups.RemoveJoinRequest)        // удалить/отклонить заявку
+	}
+}
+
+// ───────────────────────────────
+// POSTS
+// ───────────────────────────────
+func (h handlers) postRoutes(api *gin.RouterGroup) {
+	postRoutes := api.Group("/posts")
+	postRoutes.Use(auth.AuthMiddleware(""), auth.APIScope("posts"), h.limit("posts")) // любой авторизованный
+	{
+		postRoutes.POST("/", idempotency.Middleware(), h.posts.CreatePost) // повтор с тем же Idempotency-Key не создаёт дубль
+		postRoutes.GET("/", h.posts.ListPosts)
+		postRoutes.GET("/:id", h.posts.GetPost) // с ETag для If-Match
+		postRoutes.PUT("/:id", h.posts.UpdatePost)
+		postRoutes.DELETE("/:id", h.posts.DeletePost)
+		postRoutes.GET("/search", h.posts.SearchPosts)
+
+		// Likes
+		postRoutes.POST("/:id/like", h.posts.LikePost)
+		postRoutes.DELETE("/:id/like", h.posts.UnlikePost)
+		postRoutes.GET("/liked", h.posts.ListLikedPosts)
+
+		// Saves
+		postRoutes.POST("/:id/save", h.posts.SavePost)
+		postRoutes.DELETE("/:id/save", h.posts.UnsavePost)
+	}
+}
+
+// ───────────────────────────────
+// COMMENTS
+// ───────────────────────────────
+func (h handlers) commentRoutes(api *gin.RouterGroup) {
+	commentRoutes := api.Group("/posts/commentary")
+	commentRoutes.Use(auth.AuthMiddleware(""), auth.APIScope("comments"), h.limit("comments"))
+	{
+		commentRoutes.POST("/:postId", idempotency.Middleware(), h.posts.CreateComment)
+		commentRoutes.GET("/:postId", h.posts.ListComments)
+	}
+}
+
+// ───────────────────────────────
+// MESSAGES (личные сообщения)
+// ───────────────────────────────
+func (h handlers) messageRoutes(api *gin.RouterGroup) {
+	messageRoutes := api.Group("/messages")
+	messageRoutes.Use(auth.AuthMiddleware(""), auth.APIScope("messages"), h.limit("messages"))
+	{
+		messageRoutes.POST("/:chatId", idempotency.Middleware(), h.messages.SendMessage)
+		messageRoutes.GET("/:chatId", h.messages.ListMessages)
+	}
+}
+
+// ───────────────────────────────
+// GROUPS (группы/чат-группы и заявки)
+// ───────────────────────────────
+func (h handlers) groupRoutes(api *gin.RouterGroup) {
+	groupRoutes := api.Group("/groups")
+	groupRoutes.Use(auth.AuthMiddleware(""), auth.APIScope("groups"), h.limit("groups"))
+	{
+		groupRoutes.POST("/:groupId/join", h.groups.RequestJoin) // студент — запрос на вступление
+		groupRoutes.GET("/", h.groups.ListGroups)                // список групп (доступно всем авторизованным)
+	}
+}
+
+// checkSpec — каждый маршрут должен быть описан в internal/openapi/openapi.yaml;
+// маршруты без версии описаны там как /api/v1/...
+func checkSpec(r *gin.Engine) error {
+	return openapi.Check(r.Routes(), openapi.Alias{From: legacyPrefix, To: legacySuccessor})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"uniconnect/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	m.Run()
+}
+
+// каждый зарегистрированный маршрут описан в openapi.yaml, и наоборот
+func TestRoutesDocumented(t *testing.T) {
+	r := newRouter(newHandlers(nil, nil), time.Time{})
+	err := openapi.Check(r.Routes(), openapi.Alias{From: legacyPrefix, To: legacySuccessor})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// паника в обработчике превращается в 500 и попадает в http_requests_total
+func TestPanicIsCounted(t *testing.T) {
+	r := newRouter(newHandlers(nil, nil), time.Time{})
+	r.GET("/test/panic", func(c *gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	want := `uniconnect_http_requests_total{method="GET",route="/test/panic",status="500"} 1`
+	if !strings.Contains(w.Body.String(), want) {
+		t.Fatalf("/metrics has no %s", want)
+	}
+}
+
+// WebSocket без токена не открывается
+func TestWebSocketRequiresToken(t *testing.T) {
+	r := newRouter(newHandlers(nil, nil), time.Time{})
+	for _, path := range []string{"/ws/comments/1", "/ws/private/6_7"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status %d: %s", path, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"uniconnect/internal/config"
+	"uniconnect/internal/logging"
+	"uniconnect/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// спан запроса называется по шаблону маршрута, а строка лога несёт request_id и trace_id этого спана
+func TestRequestSpan(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	shutdown, err := tracing.Setup(context.Background(),
+		config.TracingConfig{ServiceName: "uniconnect", SampleRatio: 1}, tracing.WithExporter(exp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	const (
+		requestID = "test-request-0001"
+		traceID   = "4bf92f3577b34da6a3ce929d0e0e4736"
+	)
+	r := newRouter(newHandlers(nil, nil), time.Time{})
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/posts/42", nil)
+	req.Header.Set(logging.RequestIDHeader, requestID)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	var span *tracetest.SpanStub
+	for _, s := range exp.GetSpans() {
+		if s.SpanKind == trace.SpanKindServer {
+			span = &s
+		}
+	}
+	if span == nil {
+		t.Fatalf("no server span among %d spans", len(exp.GetSpans()))
+	}
+	if span.Name != "GET /api/v1/posts/:id" {
+		t.Errorf("span name %q", span.Name)
+	}
+	if !hasAttribute(span.Attributes, semconv.HTTPRoute("/api/v1/posts/:id")) {
+		t.Errorf("span attributes %v lack http.route", span.Attributes)
+	}
+	// входящий traceparent продолжается, а не начинается новая трасса
+	if got := span.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("span trace_id %s, want %s", got, traceID)
+	}
+
+	var line struct {
+		Msg       string `json:"msg"`
+		RequestID string `json:"request_id"`
+		TraceID   string `json:"trace_id"`
+		Route     string `json:"route"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &line); err != nil {
+		t.Fatalf("log line %q: %v", logs.String(), err)
+	}
+	if line.RequestID != requestID || line.TraceID != traceID || line.Route != "/api/v1/posts/:id" {
+		t.Errorf("log line %+v", line)
+	}
+	if got := w.Header().Get(logging.RequestIDHeader); got != requestID {
+		t.Errorf("%s %q", logging.RequestIDHeader, got)
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, a := range attrs {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
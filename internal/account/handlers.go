@@ -0,0 +1,252 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/config"
+	"uniconnect/internal/database"
+	"uniconnect/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Политика удаления аккаунта (config.AccountConfig.DeletionPolicy):
+//
+//	anonymize (по умолчанию) — профиль обезличивается, посты/комментарии/сообщения остаются
+//	delete                   — пользователь и весь его контент удаляются; сообщения, которые
+//	                           ему писали другие, остаются у них без получателя
+const (
+	PolicyAnonymize = "anonymize"
+	PolicyDelete    = "delete"
+)
+
+var deletionPolicy = PolicyAnonymize
+
+// Configure применяет настройки пакета
+func Configure(cfg config.AccountConfig) {
+	if cfg.DeletionPolicy != "" {
+		deletionPolicy = cfg.DeletionPolicy
+	}
+}
+
+// Export — всё, что хранится о пользователе
+type Export struct {
+	ExportedAt time.Time     `json:"exported_at"`
+	Profile    exportProfile `json:"profile"`
+	Posts      []exportPost  `json:"posts"`
+	Comments   []exportRow   `json:"comments"`
+	Likes      []exportRow   `json:"likes"`
+	Saves      []exportRow   `json:"saves"`
+	Messages   []exportRow   `json:"messages"`
+}
+
+type exportProfile struct {
+	ID               int       `db:"id" json:"id"`
+	Username         string    `db:"username" json:"username"`
+	Email            string    `db:"email" json:"email"`
+	Role             string    `db:"role" json:"role"`
+	TwoFactorEnabled bool      `db:"totp_enabled" json:"two_factor_enabled"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type exportPost struct {
+	ID        int       `db:"id" json:"id"`
+	Title     string    `db:"title" json:"title"`
+	Content   string    `db:"content" json:"content"`
+	Category  string    `db:"category" json:"category"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// exportRow — комментарии, лайки, сохранения и сообщения (набор колонок у всех разный)
+type exportRow map[string]interface{}
+
+// ExportHandler — GET /api/users/me/export, выгрузка персональных данных в JSON
+func ExportHandler(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	db := database.DB
+
+	var out Export
+	out.ExportedAt = time.Now().UTC()
+
+	err := db.GetContext(c.Request.Context(), &out.Profile, `
+		SELECT id, username, email, role, totp_enabled, created_at, updated_at
+		FROM users WHERE id=$1
+	`, userID)
+	if err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "user not found"))
+		return
+	}
+
+	out.Posts = []exportPost{}
+	err = db.SelectContext(c.Request.Context(), &out.Posts, `
+		SELECT id, title, content, category, created_at, updated_at
+		FROM posts WHERE author_id=$1 ORDER BY id
+	`, userID)
+	if err == nil {
+		out.Comments, err = selectRows(c.Request.Context(), db, `
+			SELECT id, post_id, content, created_at FROM comments WHERE author_id=$1 ORDER BY id
+		`, userID)
+	}
+	if err == nil {
+		out.Likes, err = selectRows(c.Request.Context(), db, `
+			SELECT post_id, created_at FROM post_likes WHERE user_id=$1 ORDER BY created_at
+		`, userID)
+	}
+	if err == nil {
+		out.Saves, err = selectRows(c.Request.Context(), db, `
+			SELECT post_id, created_at FROM post_saves WHERE user_id=$1 ORDER BY created_at
+		`, userID)
+	}
+	if err == nil {
+		out.Messages, err = selectRows(c.Request.Context(), db, `
+			SELECT id, sender_id, receiver_id, content, created_at
+			FROM messages WHERE sender_id=$1 OR receiver_id=$1 ORDER BY id
+		`, userID)
+	}
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("uniconnect-export-%s-%s.json", out.Profile.Username, out.ExportedAt.Format("20060102"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.IndentedJSON(http.StatusOK, out)
+}
+
+// DeleteAccountHandler — DELETE /api/users/me.
+// Подтверждение: пароль, а для аккаунтов, созданных через SSO (пароль случайный
+// и пользователю не известен), — {"confirm": "<username>"}.
+func DeleteAccountHandler(c *gin.Context) {
+	var req struct {
+		Password string `json:"password"`
+		Confirm  string `json:"confirm"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Invalid(err))
+		return
+	}
+
+	var user models.User
+	if err := database.DB.GetContext(c.Request.Context(), &user, `SELECT * FROM users WHERE id=$1`, c.GetInt("user_id")); err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "user not found"))
+		return
+	}
+
+	// с украденной сессией имени пользователя достаточно, поэтому при известном пароле нужен пароль
+	var confirmed bool
+	if user.PasswordSet {
+		confirmed = req.Password != "" && bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) == nil
+	} else {
+		confirmed = req.Confirm != "" && req.Confirm == user.Username
+	}
+	if !confirmed {
+		apperr.Abort(c, apperr.Unauthorized("confirm with your password"))
+		return
+	}
+
+	policy := deletionPolicy
+	var err error
+	switch policy {
+	case PolicyDelete:
+		err = deleteUser(c.Request.Context(), user.ID)
+	case PolicyAnonymize:
+		err = anonymizeUser(c.Request.Context(), user.ID)
+	default:
+		err = errors.New("unknown account deletion policy " + policy)
+	}
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account deleted", "policy": policy})
+}
+
+// anonymizeUser обезличивает профиль; контент остаётся от имени "deleted-<id>",
+// а членство в группах и заявки на вступление удаляются
+func anonymizeUser(ctx context.Context, userID int) error {
+	tx, err := database.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		`UPDATE users SET
+			username = 'deleted-' || id,
+			email = 'deleted-' || id || '@deleted.invalid',
+			password = '!',
+			role = 'student',
+			totp_secret = NULL, totp_enabled = false,
+			ban_reason = NULL, banned_at = NULL, banned_until = NULL,
+			tokens_valid_after = now(), deleted_at = now(), updated_at = now()
+		 WHERE id = $1`,
+		`DELETE FROM post_likes WHERE user_id = $1`,
+		`DELETE FROM post_saves WHERE user_id = $1`,
+		`DELETE FROM user_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM user_sessions WHERE user_id = $1`,
+		`DELETE FROM user_identities WHERE user_id = $1`,
+		`DELETE FROM api_keys WHERE user_id = $1`,
+		`DELETE FROM group_members WHERE user_id = $1`,
+		`DELETE FROM group_join_requests WHERE user_id = $1`,
+	}
+	for _, q := range stmts {
+		if _, err := tx.Exec(q, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteUser удаляет пользователя вместе с контентом.
+// Посты, лайки, сохранения и сессии удаляются каскадно; в полученных сообщениях
+// receiver_id становится NULL (ON DELETE SET NULL), переписка собеседников сохраняется.
+func deleteUser(ctx context.Context, userID int) error {
+	tx, err := database.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM comments WHERE author_id=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM messages WHERE sender_id=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM users WHERE id=$1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func selectRows(ctx context.Context, db *sqlx.DB, query string, args ...interface{}) ([]exportRow, error) {
+	rows, err := db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []exportRow{}
+	for rows.Next() {
+		row := exportRow{}
+		if err := rows.MapScan(row); err != nil {
+			return nil, err
+		}
+		for k, v := range row {
+			if b, ok := v.([]byte); ok {
+				row[k] = string(b)
+			}
+		}
+		out = append(out, row)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,137 @@
+package account
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	m.Run()
+}
+
+// useMockDB подменяет database.DB на sqlmock до конца теста
+func useMockDB(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := database.DB
+	database.DB = sqlx.NewDb(db, "postgres")
+	t.Cleanup(func() {
+		database.DB.Close()
+		database.DB = prev
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	return mock
+}
+
+// expectUser отдаёт строку users для SELECT * с паролем "secret"
+func expectUser(t *testing.T, mock sqlmock.Sqlmock, passwordSet bool) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM users WHERE id=\$1`).WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{
+		"id", "username", "email", "password", "password_set", "role", "is_service", "created_at", "updated_at",
+	}).AddRow(7, "alice", "alice@uni.edu", string(hash), passwordSet, "student", false, now, now))
+}
+
+// expectAnonymize ожидает транзакцию anonymizeUser целиком
+func expectAnonymize(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	for _, q := range []string{
+		`UPDATE users SET`,
+		`DELETE FROM post_likes`,
+		`DELETE FROM post_saves`,
+		`DELETE FROM user_recovery_codes`,
+		`DELETE FROM user_sessions`,
+		`DELETE FROM user_identities`,
+		`DELETE FROM api_keys`,
+		`DELETE FROM group_members`,
+		`DELETE FROM group_join_requests`,
+	} {
+		mock.ExpectExec(q).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func deleteAccount(body string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(apperr.Middleware(), func(c *gin.Context) { c.Set("user_id", 7) })
+	r.DELETE("/users/me", DeleteAccountHandler)
+	req := httptest.NewRequest(http.MethodDelete, "/users/me", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// у аккаунта с паролем имени пользователя недостаточно
+func TestDeleteAccountRequiresPassword(t *testing.T) {
+	mock := useMockDB(t)
+	expectUser(t, mock, true)
+	if w := deleteAccount(`{"confirm":"alice"}`); w.Code != http.StatusUnauthorized {
+		t.Fatalf("confirm by username: status %d: %s", w.Code, w.Body)
+	}
+
+	expectUser(t, mock, true)
+	if w := deleteAccount(`{"password":"wrong"}`); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: status %d: %s", w.Code, w.Body)
+	}
+
+	expectUser(t, mock, true)
+	expectAnonymize(mock)
+	if w := deleteAccount(`{"password":"secret"}`); w.Code != http.StatusOK {
+		t.Fatalf("password: status %d: %s", w.Code, w.Body)
+	}
+}
+
+// аккаунт, созданный через SSO, подтверждает удаление именем пользователя
+func TestDeleteSSOAccountConfirmsByUsername(t *testing.T) {
+	mock := useMockDB(t)
+	expectUser(t, mock, false)
+	if w := deleteAccount(`{"confirm":"bob"}`); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong username: status %d: %s", w.Code, w.Body)
+	}
+
+	expectUser(t, mock, false)
+	expectAnonymize(mock)
+	if w := deleteAccount(`{"confirm":"alice"}`); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+}
+
+// политика delete удаляет только отправленные сообщения: полученные остаются у собеседников
+func TestDeletePolicyKeepsReceivedMessages(t *testing.T) {
+	deletionPolicy = PolicyDelete
+	t.Cleanup(func() { deletionPolicy = PolicyAnonymize })
+
+	mock := useMockDB(t)
+	expectUser(t, mock, true)
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM comments WHERE author_id=\$1`).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM messages WHERE sender_id=\$1$`).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`DELETE FROM users WHERE id=\$1`).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if w := deleteAccount(`{"password":"secret"}`); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+}
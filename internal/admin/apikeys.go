@@ -0,0 +1,186 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/config"
+	"uniconnect/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// maxAPIKeyRate — потолок rate_limit_per_minute у API-ключа
+var maxAPIKeyRate = config.Default().RateLimit.APIKeyMaxPerMinute
+
+// Configure применяет настройки пакета
+func Configure(cfg config.RateLimitConfig) {
+	if cfg.APIKeyMaxPerMinute > 0 {
+		maxAPIKeyRate = cfg.APIKeyMaxPerMinute
+	}
+}
+
+// CreateServiceAccountHandler — сервисный аккаунт для интеграций (боты, уведомления).
+// Войти по паролю он не может, только по API-ключу.
+func CreateServiceAccountHandler(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"`
+		Email    string `json:"email"`
+		Role     string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Invalid(err))
+		return
+	}
+	if req.Role == "" {
+		req.Role = auth.RoleStudent
+	}
+	if !auth.ValidRole(req.Role) || req.Role == auth.RoleAdmin {
+		apperr.Abort(c, apperr.BadRequest("invalid role for a service account"))
+		return
+	}
+	if req.Email == "" {
+		req.Email = req.Username + "@service.uniconnect.local"
+	}
+
+	// пароль случайный и нигде не показывается
+	id, err := CreateServiceAccount(req.Username, req.Email, req.Role)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id, "username": req.Username, "role": req.Role, "is_service": true})
+}
+
+// ListServiceAccountsHandler — все сервисные аккаунты
+func ListServiceAccountsHandler(c *gin.Context) {
+	users := []UserSummary{}
+	err := database.DB.SelectContext(c.Request.Context(), &users, `SELECT `+userSummaryColumns+` FROM users WHERE is_service ORDER BY id`)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// CreateAPIKeyHandler выпускает ключ для сервисного аккаунта. Ключ возвращается один раз.
+func CreateAPIKeyHandler(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("invalid user ID"))
+		return
+	}
+
+	var req struct {
+		Name               string   `json:"name" binding:"required"`
+		Scopes             []string `json:"scopes" binding:"required"`
+		RateLimitPerMinute *int     `json:"rate_limit_per_minute"`
+		ExpiresIn          string   `json:"expires_in"` // например "720h"
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Invalid(err))
+		return
+	}
+	for _, s := range req.Scopes {
+		if !auth.ValidAPIScope(s) {
+			apperr.Abort(c, apperr.BadRequest("unknown scope "+s).WithDetails(gin.H{"allowed": auth.APIScopes}))
+			return
+		}
+	}
+
+	rateLimit := 60
+	if req.RateLimitPerMinute != nil {
+		rateLimit = *req.RateLimitPerMinute
+	}
+	// 0 или отрицательное значение отключило бы лимит ключа
+	if rateLimit <= 0 || rateLimit > maxAPIKeyRate {
+		apperr.Abort(c, apperr.BadRequest(fmt.Sprintf("rate_limit_per_minute must be between 1 and %d", maxAPIKeyRate)))
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			apperr.Abort(c, apperr.BadRequest("invalid expires_in"))
+			return
+		}
+		t := time.Now().Add(d)
+		expiresAt = &t
+	}
+
+	var isService bool
+	if err := database.DB.GetContext(c.Request.Context(), &isService, `SELECT is_service FROM users WHERE id=$1`, userID); err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "user not found"))
+		return
+	}
+	if !isService {
+		apperr.Abort(c, apperr.BadRequest("API keys can only be issued to service accounts"))
+		return
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		apperr.Abort(c, apperr.Internal(err))
+		return
+	}
+
+	var apiKey auth.APIKey
+	err = database.DB.GetContext(c.Request.Context(), &apiKey, `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, rate_limit_per_minute, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING *
+	`, userID, req.Name, prefix, hash, pq.StringArray(req.Scopes), rateLimit, c.GetInt("user_id"), expiresAt)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"key":     key,
+		"api_key": apiKey,
+		"warning": "store this key now, it cannot be shown again",
+	})
+}
+
+// ListAPIKeysHandler — ключи с информацией о последнем использовании (?user_id= для фильтра)
+func ListAPIKeysHandler(c *gin.Context) {
+	userID, _ := strconv.Atoi(c.Query("user_id"))
+
+	keys := []auth.APIKey{}
+	err := database.DB.SelectContext(c.Request.Context(), &keys, `
+		SELECT * FROM api_keys
+		WHERE ($1 = 0 OR user_id = $1)
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKeyHandler — отзыв ключа, действует сразу
+func RevokeAPIKeyHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("invalid key ID"))
+		return
+	}
+
+	res, err := database.DB.ExecContext(c.Request.Context(), `UPDATE api_keys SET revoked_at=now() WHERE id=$1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apperr.Abort(c, apperr.NotFound("active key not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked", "id": id})
+}
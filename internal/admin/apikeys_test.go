@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"uniconnect/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// лимит ключа вне 1..maxAPIKeyRate отклоняется до обращения к базе
+func TestCreateAPIKeyRateLimitBounds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useMockDB(t) // ни одного запроса не ожидается
+
+	r := gin.New()
+	r.Use(apperr.Middleware())
+	r.POST("/users/:id/api-keys", CreateAPIKeyHandler)
+
+	for _, limit := range []string{"0", "-5", "6001"} {
+		body := `{"name":"bot","scopes":["posts:read"],"rate_limit_per_minute":` + limit + `}`
+		req := httptest.NewRequest(http.MethodPost, "/users/5/api-keys", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "rate_limit_per_minute") {
+			t.Errorf("limit %s: status %d: %s", limit, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListRolesHandler — роли и их права
+func ListRolesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, auth.RolePermissions())
+}
+
+// SetUserRoleHandler — админ назначает пользователю роль
+func SetUserRoleHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("invalid user ID"))
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Invalid(err))
+		return
+	}
+	if !auth.ValidRole(req.Role) {
+		apperr.Abort(c, apperr.BadRequest("unknown role"))
+		return
+	}
+
+	// чтобы админ случайно не лишил прав сам себя
+	if id == c.GetInt("user_id") {
+		apperr.Abort(c, apperr.BadRequest("you cannot change your own role"))
+		return
+	}
+
+	if err := SetUserRole(id, req.Role); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			apperr.Abort(c, apperr.NotFound("user not found"))
+			return
+		}
+		if errors.Is(err, ErrServiceAdmin) {
+			apperr.Abort(c, apperr.BadRequest("service accounts cannot be admins"))
+			return
+		}
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role updated", "user_id": id, "role": req.Role})
+}
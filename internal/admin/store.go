@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/database"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Операции над пользователями, общие для HTTP-админки и cmd/admin
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	// ErrServiceAdmin — сервисному аккаунту нельзя дать роль admin: его API-ключ
+	// получил бы все права админки без второго фактора
+	ErrServiceAdmin = errors.New("service accounts cannot be admins")
+)
+
+// CreateUser создаёт пользователя с указанной ролью и возвращает его ID
+func CreateUser(username, email, password, role string) (int, error) {
+	return insertUser(username, email, password, role, false)
+}
+
+// CreateServiceAccount создаёт сервисный аккаунт (вход только по API-ключу) со случайным паролем.
+// is_service ставится тем же INSERT: обычный пользователь с этой ролью не может остаться в базе.
+func CreateServiceAccount(username, email, role string) (int, error) {
+	password, err := GeneratePassword()
+	if err != nil {
+		return 0, err
+	}
+	return insertUser(username, email, password, role, true)
+}
+
+func insertUser(username, email, password, role string, service bool) (int, error) {
+	if !auth.ValidRole(role) {
+		return 0, fmt.Errorf("unknown role %q", role)
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = database.DB.Get(&id, `
+		INSERT INTO users (username, email, password, role, is_service) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, username, email, string(hashed), role, service)
+	return id, err
+}
+
+// FindUserID ищет пользователя по имени
+func FindUserID(username string) (int, error) {
+	var id int
+	err := database.DB.Get(&id, `SELECT id FROM users WHERE username=$1`, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrUserNotFound
+	}
+	return id, err
+}
+
+// SetUserRole меняет роль пользователя; сервисный аккаунт не может стать админом
+func SetUserRole(id int, role string) error {
+	if !auth.ValidRole(role) {
+		return fmt.Errorf("unknown role %q", role)
+	}
+	res, err := database.DB.Exec(`
+		UPDATE users SET role=$1, updated_at=now()
+		WHERE id=$2 AND NOT (is_service AND $1 = 'admin')
+	`, role, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	// ничего не обновилось: пользователя нет или это сервисный аккаунт
+	var isService bool
+	err = database.DB.Get(&isService, `SELECT is_service FROM users WHERE id=$1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if isService {
+		return ErrServiceAdmin
+	}
+	return ErrUserNotFound
+}
+
+// SetPassword задаёт новый пароль и завершает все сессии пользователя
+func SetPassword(id int, password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	res, err := database.DB.Exec(`
+		UPDATE users SET password=$1, password_set=true, tokens_valid_after=now(), updated_at=now() WHERE id=$2
+	`, string(hashed), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+	return auth.RevokeAllSessions(id)
+}
+
+// ListUsers — поиск по имени/почте, фильтр по роли и активному бану
+func ListUsers(q, role string, bannedOnly bool, limit, offset int) ([]UserSummary, error) {
+	users := []UserSummary{}
+	err := database.DB.Select(&users, `
+		SELECT `+userSummaryColumns+`
+		FROM users
+		WHERE ($1 = '' OR username ILIKE '%' || $1 || '%' OR email ILIKE '%' || $1 || '%')
+		  AND ($2 = '' OR role = $2)
+		  AND (NOT $3 OR (banned_at IS NOT NULL AND (banned_until IS NULL OR banned_until > now())))
+		ORDER BY id
+		LIMIT $4 OFFSET $5
+	`, q, role, bannedOnly, limit, offset)
+	return users, err
+}
+
+// GeneratePassword — временный пароль для сброса
+func GeneratePassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// useMockDB подменяет database.DB на sqlmock до конца теста
+func useMockDB(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := database.DB
+	database.DB = sqlx.NewDb(db, "postgres")
+	t.Cleanup(func() {
+		database.DB.Close()
+		database.DB = prev
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	return mock
+}
+
+func TestSetUserRole(t *testing.T) {
+	mock := useMockDB(t)
+	mock.ExpectExec(`UPDATE users SET role=\$1`).WithArgs(auth.RoleTeacher, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := SetUserRole(5, auth.RoleTeacher); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// условие на is_service стоит в самом UPDATE; если строка не обновилась, причину уточняем отдельно
+func TestSetUserRoleRefusesServiceAdmin(t *testing.T) {
+	mock := useMockDB(t)
+	mock.ExpectExec(`WHERE id=\$2 AND NOT \(is_service AND \$1 = 'admin'\)`).WithArgs(auth.RoleAdmin, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT is_service FROM users`).WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"is_service"}).AddRow(true))
+	if err := SetUserRole(5, auth.RoleAdmin); !errors.Is(err, ErrServiceAdmin) {
+		t.Fatalf("err %v, want ErrServiceAdmin", err)
+	}
+}
+
+func TestSetUserRoleNotFound(t *testing.T) {
+	mock := useMockDB(t)
+	mock.ExpectExec(`UPDATE users SET role=\$1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT is_service FROM users`).WillReturnRows(sqlmock.NewRows([]string{"is_service"}))
+	if err := SetUserRole(9, auth.RoleAdmin); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("err %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestSetUserRoleHandlerRefusesServiceAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mock := useMockDB(t)
+	mock.ExpectExec(`UPDATE users SET role=\$1`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT is_service FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"is_service"}).AddRow(true))
+
+	r := gin.New()
+	r.Use(apperr.Middleware(), func(c *gin.Context) { c.Set("user_id", 1) })
+	r.PUT("/users/:id/role", SetUserRoleHandler)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/users/5/role", strings.NewReader(`{"role":"admin"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "service accounts cannot be admins") {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+}
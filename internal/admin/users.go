@@ -0,0 +1,241 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserSummary — пользователь в админке (без пароля и секретов)
+type UserSummary struct {
+	ID               int        `db:"id" json:"id"`
+	Username         string     `db:"username" json:"username"`
+	Email            string     `db:"email" json:"email"`
+	Role             string     `db:"role" json:"role"`
+	IsService        bool       `db:"is_service" json:"is_service"`
+	TwoFactorEnabled bool       `db:"totp_enabled" json:"two_factor_enabled"`
+	BannedAt         *time.Time `db:"banned_at" json:"banned_at,omitempty"`
+	BannedUntil      *time.Time `db:"banned_until" json:"banned_until,omitempty"`
+	BanReason        *string    `db:"ban_reason" json:"ban_reason,omitempty"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+}
+
+const userSummaryColumns = `id, username, email, role, is_service, totp_enabled, banned_at, banned_until, ban_reason, created_at`
+
+// DashboardHandler — сводка для админки
+func DashboardHandler(c *gin.Context) {
+	var stats struct {
+		Users    int `db:"users" json:"users"`
+		Banned   int `db:"banned" json:"banned"`
+		Posts    int `db:"posts" json:"posts"`
+		Comments int `db:"comments" json:"comments"`
+	}
+	err := database.DB.GetContext(c.Request.Context(), &stats, `
+		SELECT
+			(SELECT COUNT(*) FROM users) AS users,
+			(SELECT COUNT(*) FROM users
+			  WHERE banned_at IS NOT NULL AND (banned_until IS NULL OR banned_until > now())) AS banned,
+			(SELECT COUNT(*) FROM posts) AS posts,
+			(SELECT COUNT(*) FROM comments) AS comments
+	`)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Welcome admin", "stats": stats})
+}
+
+// ListUsersHandler — поиск пользователей: ?q=, ?role=, ?banned=true, ?page=, ?limit=
+func ListUsersHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	users, err := ListUsers(c.Query("q"), c.Query("role"), c.Query("banned") == "true", limit, offset)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUserHandler — карточка пользователя
+func GetUserHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("invalid user ID"))
+		return
+	}
+
+	var user UserSummary
+	err = database.DB.GetContext(c.Request.Context(), &user, `SELECT `+userSummaryColumns+` FROM users WHERE id=$1`, id)
+	if err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "user not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// BanUserHandler — бан или временная блокировка.
+// Без duration/until бан бессрочный; duration в формате Go ("72h"), until — RFC 3339.
+func BanUserHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("invalid user ID"))
+		return
+	}
+
+	var req struct {
+		Reason   string     `json:"reason" binding:"required"`
+		Duration string     `json:"duration"`
+		Until    *time.Time `json:"until"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Invalid(err))
+		return
+	}
+
+	until := req.Until
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			apperr.Abort(c, apperr.BadRequest("invalid duration"))
+			return
+		}
+		t := time.Now().Add(d)
+		until = &t
+	}
+	if until != nil && !until.After(time.Now()) {
+		apperr.Abort(c, apperr.BadRequest("ban expiry must be in the future"))
+		return
+	}
+
+	if id == c.GetInt("user_id") {
+		apperr.Abort(c, apperr.BadRequest("you cannot ban yourself"))
+		return
+	}
+
+	var role string
+	if err := database.DB.GetContext(c.Request.Context(), &role, `SELECT role FROM users WHERE id=$1`, id); err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "user not found"))
+		return
+	}
+	if role == auth.RoleAdmin {
+		apperr.Abort(c, apperr.Forbidden("admins cannot be banned, change their role first"))
+		return
+	}
+
+	_, err = database.DB.ExecContext(c.Request.Context(), `
+		UPDATE users SET banned_at=now(), banned_until=$1, ban_reason=$2, updated_at=now()
+		WHERE id=$3
+	`, until, req.Reason, id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user banned", "user_id": id, "until": until})
+}
+
+// UnbanUserHandler — снять бан
+func UnbanUserHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("invalid user ID"))
+		return
+	}
+
+	res, err := database.DB.ExecContext(c.Request.Context(), `
+		UPDATE users SET banned_at=NULL, banned_until=NULL, ban_reason=NULL, updated_at=now()
+		WHERE id=$1
+	`, id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apperr.Abort(c, apperr.NotFound("user not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user unbanned", "user_id": id})
+}
+
+// ForceLogoutHandler — аннулирует все выданные пользователю токены
+func ForceLogoutHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("invalid user ID"))
+		return
+	}
+
+	res, err := database.DB.ExecContext(c.Request.Context(), `UPDATE users SET tokens_valid_after=now() WHERE id=$1`, id)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apperr.Abort(c, apperr.NotFound("user not found"))
+		return
+	}
+	if err := auth.RevokeAllSessions(id); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user logged out", "user_id": id})
+}
+
+// ResetPasswordHandler — задаёт новый пароль (или генерирует временный) и разлогинивает пользователя
+func ResetPasswordHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("invalid user ID"))
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+	}
+	// тело необязательное
+	_ = c.ShouldBindJSON(&req)
+
+	password := req.Password
+	generated := password == ""
+	if generated {
+		if password, err = GeneratePassword(); err != nil {
+			apperr.Abort(c, apperr.Internal(err))
+			return
+		}
+	}
+
+	if err := SetPassword(id, password); err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			apperr.Abort(c, apperr.NotFound("user not found"))
+			return
+		}
+		apperr.Abort(c, err)
+		return
+	}
+
+	resp := gin.H{"message": "password reset", "user_id": id}
+	if generated {
+		resp["temporary_password"] = password
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,79 @@
+// Package apiversion — версии API: наборы маршрутов под /api/<версия>
+// и заголовки устаревания для старых адресов (RFC 9745 Deprecation, RFC 8594 Sunset).
+package apiversion
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Routes регистрирует часть API (auth, posts, ...) в группе версии
+type Routes func(api *gin.RouterGroup)
+
+// Version — версия API: её префикс и наборы маршрутов. Новая версия обычно
+// берёт наборы предыдущей и заменяет те, в которых есть несовместимые изменения.
+type Version struct {
+	Name   string // "v1" → /api/v1
+	Routes []Routes
+}
+
+// Register регистрирует версии рядом друг с другом: /api/v1, /api/v2, ...
+func Register(api *gin.RouterGroup, versions ...Version) {
+	for _, v := range versions {
+		g := api.Group("/" + v.Name)
+		for _, routes := range v.Routes {
+			routes(g)
+		}
+	}
+}
+
+// Deprecation — политика для устаревших маршрутов
+type Deprecation struct {
+	Since  time.Time // с какого момента маршруты устарели
+	Sunset time.Time // когда их отключат; нулевое — дата ещё не назначена
+	// From и To — префиксы старого и нового пути: /api/posts/1 → /api/v1/posts/1
+	From, To string
+	Docs     string // ссылка на описание миграции, необязательно
+}
+
+// Deprecated добавляет к ответам устаревших маршрутов заголовки
+//
+//	Deprecation: @1760832000
+//	Sunset: Wed, 30 Jun 2027 00:00:00 GMT
+//	Link: </api/v1/posts/1>; rel="successor-version"
+func Deprecated(d Deprecation) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", d.Since.Unix())
+	sunset := ""
+	if !d.Sunset.IsZero() {
+		sunset = d.Sunset.UTC().Format(http.TimeFormat)
+	}
+	return func(c *gin.Context) {
+		c.Set(routeKey, d.To+strings.TrimPrefix(c.FullPath(), d.From))
+		header := c.Writer.Header()
+		header.Set("Deprecation", deprecation)
+		if sunset != "" {
+			header.Set("Sunset", sunset)
+		}
+		successor := d.To + strings.TrimPrefix(c.Request.URL.Path, d.From)
+		header.Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		if d.Docs != "" {
+			header.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, d.Docs))
+		}
+		c.Next()
+	}
+}
+
+const routeKey = "apiversion.route"
+
+// Route — шаблон маршрута запроса; для устаревших адресов — шаблон преемника
+// (/api/posts/:id → /api/v1/posts/:id), чтобы оба адреса считались одним маршрутом
+func Route(c *gin.Context) string {
+	if r := c.GetString(routeKey); r != "" {
+		return r
+	}
+	return c.FullPath()
+}
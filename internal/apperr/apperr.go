@@ -0,0 +1,236 @@
+// Package apperr — ошибки приложения и единый формат ответа с ошибкой:
+//
+//	{"code": "not_found", "message": "post not found", "details": ..., "request_id": "..."}
+//
+// Обработчик вызывает Abort(c, err); ответ формирует Middleware. Внутренности
+// (текст ошибки БД, имена таблиц и ограничений) клиенту не отдаются — они остаются в c.Errors
+// и попадают в лог.
+package apperr
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+)
+
+// Коды ошибок, на которые может опираться клиент
+const (
+	CodeBadRequest           = "bad_request"
+	CodeValidation           = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePreconditionRequired = "precondition_required"
+	CodeRateLimited          = "rate_limited"
+	CodeUnavailable          = "unavailable"
+	CodeInternal             = "internal"
+	CodeAccountBanned        = "account_suspended"
+)
+
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details interface{}
+	Err     error // причина для лога, клиенту не показывается
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// WithDetails добавляет к ошибке данные для клиента (поля, лимиты и т.п.)
+func (e *Error) WithDetails(details interface{}) *Error {
+	cp := *e
+	cp.Details = details
+	return &cp
+}
+
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// PreconditionFailed — If-Match не совпал с текущей версией ресурса (412)
+func PreconditionFailed(message string) *Error {
+	return New(http.StatusPreconditionFailed, CodePreconditionFailed, message)
+}
+
+// PreconditionRequired — запрос должен содержать If-Match (428)
+func PreconditionRequired(message string) *Error {
+	return New(http.StatusPreconditionRequired, CodePreconditionRequired, message)
+}
+
+func Unprocessable(message string) *Error {
+	return New(http.StatusUnprocessableEntity, CodeValidation, message)
+}
+
+func TooManyRequests(message string) *Error {
+	return New(http.StatusTooManyRequests, CodeRateLimited, message)
+}
+
+func Unavailable(message string, err error) *Error {
+	e := New(http.StatusServiceUnavailable, CodeUnavailable, message)
+	e.Err = err
+	return e
+}
+
+// Internal — непредвиденная ошибка; клиент увидит только "internal server error"
+func Internal(err error) *Error {
+	e := New(http.StatusInternalServerError, CodeInternal, "internal server error")
+	e.Err = err
+	return e
+}
+
+// FieldError — ошибка валидации одного поля запроса
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+}
+
+// в details.field — имя из json-тега, а не имя поля структуры
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				return f.Name
+			}
+			return name
+		})
+	}
+}
+
+// Invalid — ошибка разбора/валидации тела запроса (результат ShouldBindJSON и т.п.)
+func Invalid(err error) *Error {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		fields := make([]FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			fields = append(fields, FieldError{Field: fe.Field(), Rule: fe.Tag()})
+		}
+		e := Unprocessable("request validation failed").WithDetails(fields)
+		e.Err = err
+		return e
+	}
+
+	e := BadRequest("malformed request body")
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		e = e.WithDetails(FieldError{Field: typeErr.Field, Rule: "type"})
+	case errors.As(err, &syntaxErr):
+		e = e.WithDetails(gin.H{"offset": syntaxErr.Offset})
+	}
+	e.Err = err
+	return e
+}
+
+// OrNotFound: sql.ErrNoRows превращается в 404 с понятным текстом, прочие ошибки не меняются
+func OrNotFound(err error, message string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		e := NotFound(message)
+		e.Err = err
+		return e
+	}
+	return err
+}
+
+// From приводит любую ошибку к *Error: ошибки приложения — как есть,
+// ошибки БД — по коду Postgres, остальное — 500.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		e := NotFound("resource not found")
+		e.Err = err
+		return e
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		var e *Error
+		switch pqErr.Code {
+		case "23505": // unique_violation
+			e = Conflict("resource already exists")
+		case "23503": // foreign_key_violation
+			e = Unprocessable("referenced resource does not exist")
+		case "23502": // not_null_violation
+			e = Unprocessable("required field is missing")
+		case "22P02", "22001", "23514": // invalid_text_representation, string_data_right_truncation, check_violation
+			e = Unprocessable("invalid field value")
+		}
+		if e != nil {
+			e.Err = err
+			return e
+		}
+	}
+	return Internal(err)
+}
+
+// Abort запоминает ошибку и останавливает цепочку обработчиков; ответ пишет Middleware
+func Abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// Middleware выводит последнюю ошибку из c.Errors, если обработчик сам ничего не ответил
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		Render(c, c.Errors.Last().Err)
+	}
+}
+
+// Render сразу пишет ответ с ошибкой (для мест вне цепочки, например recovery)
+func Render(c *gin.Context, err error) {
+	e := From(err)
+	body := gin.H{"code": e.Code, "message": e.Message}
+	if e.Details != nil {
+		body["details"] = e.Details
+	}
+	if id := c.GetString("request_id"); id != "" {
+		body["request_id"] = id
+	}
+	c.AbortWithStatusJSON(e.Status, body)
+}
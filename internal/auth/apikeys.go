@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/database"
+	"uniconnect/internal/redis"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// Ключи имеют вид uck_<prefix>_<secret>. По prefix ключ ищется в БД,
+// хранится только sha256 от ключа целиком.
+const apiKeyPrefix = "uck_"
+
+// Области доступа API-ключей: "<ресурс>:read" для GET/HEAD, "<ресурс>:write" для остального
+var APIScopes = []string{
+	"profile:read",
+	"posts:read", "posts:write",
+	"comments:read", "comments:write",
+	"messages:read", "messages:write",
+	"groups:read", "groups:write",
+	"admin:read", "admin:write",
+}
+
+// APIKey — запись из таблицы api_keys
+type APIKey struct {
+	ID                 int            `db:"id" json:"id"`
+	UserID             int            `db:"user_id" json:"user_id"`
+	Name               string         `db:"name" json:"name"`
+	Prefix             string         `db:"prefix" json:"prefix"`
+	KeyHash            string         `db:"key_hash" json:"-"`
+	Scopes             pq.StringArray `db:"scopes" json:"scopes"`
+	RateLimitPerMinute int            `db:"rate_limit_per_minute" json:"rate_limit_per_minute"`
+	LastUsedAt         *time.Time     `db:"last_used_at" json:"last_used_at"`
+	LastUsedIP         *string        `db:"last_used_ip" json:"last_used_ip"`
+	CreatedBy          *int           `db:"created_by" json:"created_by"`
+	CreatedAt          time.Time      `db:"created_at" json:"created_at"`
+	ExpiresAt          *time.Time     `db:"expires_at" json:"expires_at"`
+	RevokedAt          *time.Time     `db:"revoked_at" json:"revoked_at"`
+}
+
+// ValidAPIScope сообщает, известна ли область доступа
+func ValidAPIScope(scope string) bool {
+	for _, s := range APIScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateAPIKey возвращает ключ (показывается один раз), его префикс и хэш для БД
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	p := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err = rand.Read(p); err != nil {
+		return
+	}
+	if _, err = rand.Read(secret); err != nil {
+		return
+	}
+	prefix = hex.EncodeToString(p)
+	key = apiKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, hashAPIKey(key), nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if h := c.GetHeader("Authorization"); strings.HasPrefix(h, "ApiKey ") {
+		return strings.TrimPrefix(h, "ApiKey ")
+	}
+	return ""
+}
+
+func authenticateAPIKey(c *gin.Context, key, requiredRole string) {
+	prefix, _, ok := strings.Cut(strings.TrimPrefix(key, apiKeyPrefix), "_")
+	if !strings.HasPrefix(key, apiKeyPrefix) || !ok {
+		apperr.Abort(c, apperr.Unauthorized("invalid API key"))
+		return
+	}
+
+	var apiKey APIKey
+	err := database.DB.GetContext(c.Request.Context(), &apiKey, `SELECT * FROM api_keys WHERE prefix=$1`, prefix)
+	if err != nil || subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(hashAPIKey(key))) != 1 {
+		apperr.Abort(c, apperr.Unauthorized("invalid API key"))
+		return
+	}
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(time.Now())) {
+		apperr.Abort(c, apperr.Unauthorized("API key revoked or expired"))
+		return
+	}
+
+	user, err := loadUser(c.Request.Context(), apiKey.UserID)
+	if err != nil || !user.IsService {
+		apperr.Abort(c, apperr.Unauthorized("invalid API key"))
+		return
+	}
+
+	if !allowAPIKeyRequest(c, apiKey) {
+		return
+	}
+	touchAPIKey(apiKey.ID, c.ClientIP())
+
+	c.Set("api_key_id", apiKey.ID)
+	c.Set("scopes", []string(apiKey.Scopes))
+	authorize(c, user, requiredRole)
+}
+
+// allowAPIKeyRequest — лимит запросов в минуту на ключ (фиксированное окно в Redis)
+func allowAPIKeyRequest(c *gin.Context, apiKey APIKey) bool {
+	if apiKey.RateLimitPerMinute <= 0 {
+		return true
+	}
+
+	window := time.Now().Unix() / 60
+	rkey := fmt.Sprintf("apikey:rl:%d:%d", apiKey.ID, window)
+	count, err := redis.Rdb.Incr(c.Request.Context(), rkey).Result()
+	if err != nil {
+		// Redis недоступен — не блокируем интеграции
+		return true
+	}
+	if count == 1 {
+		redis.Rdb.Expire(c.Request.Context(), rkey, time.Minute)
+	}
+
+	remaining := int64(apiKey.RateLimitPerMinute) - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.Header("X-RateLimit-Limit", strconv.Itoa(apiKey.RateLimitPerMinute))
+	c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+
+	if count > int64(apiKey.RateLimitPerMinute) {
+		c.Header("Retry-After", strconv.FormatInt(60-time.Now().Unix()%60, 10))
+		apperr.Abort(c, apperr.TooManyRequests("API key rate limit exceeded"))
+		return false
+	}
+	return true
+}
+
+// touchAPIKey обновляет last_used не чаще раза в минуту, чтобы не писать в БД на каждый запрос
+func touchAPIKey(id int, ip string) {
+	_, _ = database.DB.Exec(`
+		UPDATE api_keys SET last_used_at=now(), last_used_ip=$1
+		WHERE id=$2 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute' OR last_used_ip IS DISTINCT FROM $1)
+	`, ip, id)
+}
+
+// APIScope ограничивает запросы по API-ключу областью доступа ресурса.
+// Запросы с JWT пропускаются без проверки.
+func APIScope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		need := resource + ":write"
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			need = resource + ":read"
+		}
+		if !HasAPIScope(c, need) {
+			apperr.Abort(c, apperr.Forbidden("API key is missing scope").WithDetails(gin.H{"required": need}))
+			return
+		}
+		c.Next()
+	}
+}
+
+// HasAPIScope сообщает, разрешена ли запросу область доступа; запросам с JWT разрешено всё
+func HasAPIScope(c *gin.Context, scope string) bool {
+	v, ok := c.Get("scopes")
+	if !ok {
+		return true
+	}
+	for _, s := range v.([]string) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -1,73 +1,103 @@
-// internal/auth/handlers.go
-package auth
-
-import (
-    "net/http"
-    "time"
-    "uniconnect/internal/database"
-    "uniconnect/internal/models"
-
-    "github.com/gin-gonic/gin"
-    "golang.org/x/crypto/bcrypt"
-    "github.com/golang-jwt/jwt/v5"
-)
-
-var jwtKey = []byte("supersecretkey")
-
-type Credentials struct {
-    Username string `json:"username"`
-    Password string `json:"password"`
-    Email    string `json:"email,omitempty"`
-}
-
-func RegisterHandler(c *gin.Context) {
-    var creds Credentials
-    if err := c.ShouldBindJSON(&creds); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-
-    hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(creds.Password), bcrypt.DefaultCost)
-
-    _, err := database.DB.Exec(`
-        INSERT INTO users (username, email, password) VALUES ($1, $2, $3)
-    `, creds.Username, creds.Email, string(hashedPassword))
-
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
-
-    c.JSON(http.StatusOK, gin.H{"message": "user registered"})
-}
-
-func LoginHandler(c *gin.Context) {
-    var creds Credentials
-    if err := c.ShouldBindJSON(&creds); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-
-    var user models.User
-    err := database.DB.Get(&user, "SELECT * FROM users WHERE username=$1", creds.Username)
-    if err != nil {
-        c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
-        return
-    }
-
-    err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(creds.Password))
-    if err != nil {
-        c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
-        return
-    }
-
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-        "user_id":  user.ID,
-        "username": user.Username,
-        "role":     user.Role,
-        "exp":      time.Now().Add(time.Hour * 24).Unix(),
-    })
-
-    tokenString, _ := token.SignedString(jwtKey)
-    c.JSON(http.StatusOK, gin.H{"token": tokenString})
-}
+// internal/auth/handlers.go
+package auth
+
+import (
+    "net/http"
+    "time"
+    "uniconnect/internal/apperr"
+    "uniconnect/internal/models"
+    "uniconnect/internal/repository"
+
+    "github.com/gin-gonic/gin"
+    "golang.org/x/crypto/bcrypt"
+    "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+    challengePurpose = "2fa_challenge"
+    challengeTTL     = 5 * time.Minute
+)
+
+type Credentials struct {
+    Username string `json:"username"`
+    Password string `json:"password"`
+    Email    string `json:"email,omitempty"`
+    Device   string `json:"device,omitempty"` // название устройства для списка сессий
+}
+
+// Handler — регистрация, вход и профиль; пользователи через UserRepo
+type Handler struct {
+    Users repository.UserRepo
+}
+
+func NewHandler(users repository.UserRepo) *Handler {
+    return &Handler{Users: users}
+}
+
+func (h *Handler) Register(c *gin.Context) {
+    var creds Credentials
+    if err := c.ShouldBindJSON(&creds); err != nil {
+        apperr.Abort(c, apperr.Invalid(err))
+        return
+    }
+
+    hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(creds.Password), bcrypt.DefaultCost)
+
+    user := models.User{Username: creds.Username, Email: creds.Email, Password: string(hashedPassword)}
+    if err := h.Users.Create(c.Request.Context(), &user); err != nil {
+        apperr.Abort(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "user registered"})
+}
+
+func (h *Handler) Login(c *gin.Context) {
+    var creds Credentials
+    if err := c.ShouldBindJSON(&creds); err != nil {
+        apperr.Abort(c, apperr.Invalid(err))
+        return
+    }
+
+    user, err := h.Users.ByUsername(c.Request.Context(), creds.Username)
+    if err != nil {
+        apperr.Abort(c, apperr.Unauthorized("invalid credentials"))
+        return
+    }
+
+    err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(creds.Password))
+    if err != nil || user.IsService {
+        apperr.Abort(c, apperr.Unauthorized("invalid credentials"))
+        return
+    }
+
+    if user.IsBanned(time.Now()) {
+        apperr.Abort(c, bannedError(user))
+        return
+    }
+
+    if user.TOTPEnabled {
+        challenge, err := issueChallengeToken(user)
+        if err != nil {
+            apperr.Abort(c, apperr.Internal(err))
+            return
+        }
+        c.JSON(http.StatusOK, gin.H{
+            "two_factor_required": true,
+            "challenge_token":     challenge,
+        })
+        return
+    }
+
+    respondWithSession(c, user, creds.Device, false)
+}
+
+// issueChallengeToken выдаёт короткоживущий токен для второго шага входа.
+// AuthMiddleware такие токены не принимает.
+func issueChallengeToken(user models.User) (string, error) {
+    return signToken(jwt.MapClaims{
+        "user_id": user.ID,
+        "purpose": challengePurpose,
+        "exp":     time.Now().Add(challengeTTL).Unix(),
+    })
+}
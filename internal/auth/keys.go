@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"uniconnect/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keyRing — ключ подписи и все ключи, которыми ещё можно проверять токены.
+// При ротации новый ключ становится ключом подписи, а старый остаётся
+// в verify до истечения выданных им токенов.
+type keyRing struct {
+	method     jwt.SigningMethod
+	signingKID string
+	signingKey interface{}
+	verify     map[string]interface{} // kid -> ключ проверки
+}
+
+var keys *keyRing
+
+// Configure применяет настройки аутентификации и загружает ключи JWT.
+// Для HS256 нужен JWTSecret, для RS256/EdDSA — PEM с приватным ключом.
+// JWTVerifyKeys — дополнительные ключи проверки (kid -> PEM с публичным ключом
+// или файл с секретом для HS256), нужны на время ротации.
+func Configure(cfg config.AuthConfig) error {
+	require2FAForAdmin = cfg.Require2FAForAdmin
+
+	alg := cfg.JWTAlgorithm
+	if alg == "" {
+		alg = jwt.SigningMethodHS256.Alg()
+	}
+
+	ring := &keyRing{verify: map[string]interface{}{}}
+	switch alg {
+	case jwt.SigningMethodHS256.Alg():
+		ring.method = jwt.SigningMethodHS256
+		// случайный ключ на каждый запуск обнулил бы токены при рестарте и между репликами
+		secret := []byte(cfg.JWTSecret)
+		if len(secret) < config.MinJWTSecretLen {
+			return fmt.Errorf("auth.jwt_secret must be at least %d bytes for HS256", config.MinJWTSecretLen)
+		}
+		ring.signingKey = secret
+		ring.signingKID = keyID(secret)
+
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg():
+		ring.method = jwt.GetSigningMethod(alg)
+		path := cfg.JWTPrivateKeyFile
+		if path == "" {
+			return fmt.Errorf("auth.jwt_private_key_file is required for %s", alg)
+		}
+		priv, err := readPrivateKey(path, alg)
+		if err != nil {
+			return err
+		}
+		ring.signingKey = priv
+		ring.signingKID = keyID(priv.(crypto.Signer).Public())
+
+	default:
+		return fmt.Errorf("unsupported JWT algorithm %q", alg)
+	}
+
+	if kid := cfg.JWTKeyID; kid != "" {
+		ring.signingKID = kid
+	}
+	ring.verify[ring.signingKID] = verificationKey(ring.signingKey)
+
+	for kid, path := range cfg.JWTVerifyKeys {
+		key, err := readVerificationKey(path, alg)
+		if err != nil {
+			return fmt.Errorf("jwt verify key %s: %w", kid, err)
+		}
+		ring.verify[kid] = key
+	}
+
+	keys = ring
+	log.Printf("JWT keys loaded: alg=%s kid=%s verification keys=%d", alg, ring.signingKID, len(ring.verify))
+	return nil
+}
+
+// signToken подписывает claims текущим ключом и проставляет kid
+func signToken(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(keys.method, claims)
+	token.Header["kid"] = keys.signingKID
+	return token.SignedString(keys.signingKey)
+}
+
+// parseToken проверяет подпись, алгоритм и срок действия токена
+func parseToken(tokenStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			// токены, выданные до появления kid
+			kid = keys.signingKID
+		}
+		key, ok := keys.verify[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{keys.method.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	return token.Claims.(jwt.MapClaims), nil
+}
+
+// JWKSHandler публикует публичные ключи проверки, чтобы другие сервисы
+// кампуса могли проверять наши токены. Для HS256 список пуст — секрет не публикуется.
+func JWKSHandler(c *gin.Context) {
+	out := []gin.H{}
+	for kid, key := range keys.verify {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			out = append(out, gin.H{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": jwt.SigningMethodRS256.Alg(),
+				"kid": kid,
+				"n":   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			out = append(out, gin.H{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"use": "sig",
+				"alg": jwt.SigningMethodEdDSA.Alg(),
+				"kid": kid,
+				"x":   base64.RawURLEncoding.EncodeToString(k),
+			})
+		}
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": out})
+}
+
+func verificationKey(signingKey interface{}) interface{} {
+	if signer, ok := signingKey.(crypto.Signer); ok {
+		return signer.Public()
+	}
+	return signingKey
+}
+
+// keyID — короткий отпечаток ключа, стабильный между перезапусками
+func keyID(key interface{}) string {
+	var raw []byte
+	switch k := key.(type) {
+	case []byte:
+		raw = k
+	default:
+		der, err := x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			return "default"
+		}
+		raw = der
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:8])
+}
+
+func readPrivateKey(path, alg string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+
+	var key interface{}
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if alg == jwt.SigningMethodRS256.Alg() {
+			return k, nil
+		}
+	case ed25519.PrivateKey:
+		if alg == jwt.SigningMethodEdDSA.Alg() {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: key type %T does not match %s", path, key, alg)
+}
+
+func readVerificationKey(path, alg string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if alg == jwt.SigningMethodHS256.Alg() {
+		secret := []byte(strings.TrimSpace(string(data)))
+		if len(secret) == 0 {
+			return nil, errors.New("empty secret")
+		}
+		return secret, nil
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg == jwt.SigningMethodRS256.Alg() {
+			return k, nil
+		}
+	case ed25519.PublicKey:
+		if alg == jwt.SigningMethodEdDSA.Alg() {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("key type %T does not match %s", key, alg)
+}
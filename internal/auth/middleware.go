@@ -1,39 +1,128 @@
-package auth
-
-import (
-    "net/http"
-    "strings"
-
-    "github.com/gin-gonic/gin"
-    "github.com/golang-jwt/jwt/v5"
-)
-
-func AuthMiddleware(requiredRole string) gin.HandlerFunc {
-    return func(c *gin.Context) {
-        authHeader := c.GetHeader("Authorization")
-        if authHeader == "" {
-            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "no token"})
-            return
-        }
-
-        tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-        token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-            return jwtKey, nil
-        })
-        if err != nil || !token.Valid {
-            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-            return
-        }
-
-        claims := token.Claims.(jwt.MapClaims)
-        role := claims["role"].(string)
-        if requiredRole != "" && role != requiredRole {
-            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
-            return
-        }
-        c.Set("user_id", int(claims["user_id"].(float64)))
-        c.Set("username", claims["username"])
-        c.Set("role", role)
-        c.Next()
-    }
-}
+package auth
+
+import (
+    "net/http"
+    "strings"
+    "time"
+
+    "uniconnect/internal/apperr"
+    "uniconnect/internal/models"
+
+    "github.com/gin-gonic/gin"
+)
+
+// require2FAForAdmin — если включено, админ без второго фактора
+// может пользоваться только эндпоинтами настройки 2FA (см. Configure)
+var require2FAForAdmin bool
+
+// AuthMiddleware принимает JWT (Authorization: Bearer ...) или API-ключ
+// сервисного аккаунта (X-API-Key или Authorization: ApiKey ...)
+func AuthMiddleware(requiredRole string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if key := apiKeyFromRequest(c); key != "" {
+            authenticateAPIKey(c, key, requiredRole)
+            return
+        }
+
+        authHeader := c.GetHeader("Authorization")
+        if authHeader == "" {
+            apperr.Abort(c, apperr.Unauthorized("no token"))
+            return
+        }
+
+        tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+        claims, err := parseToken(tokenStr)
+        if err != nil {
+            apperr.Abort(c, apperr.Unauthorized("invalid token"))
+            return
+        }
+
+        // challenge-токены годятся только для POST /api/auth/login/2fa
+        if _, ok := claims["purpose"]; ok {
+            apperr.Abort(c, apperr.Unauthorized("invalid token"))
+            return
+        }
+
+        userID, _ := claims["user_id"].(float64)
+        user, err := loadUser(c.Request.Context(), int(userID))
+        if err != nil {
+            apperr.Abort(c, apperr.Unauthorized("invalid token"))
+            return
+        }
+        if user.IsService {
+            apperr.Abort(c, apperr.Unauthorized("invalid token"))
+            return
+        }
+        // принудительный выход: токены, выданные до tokens_valid_after, больше не действуют.
+        // iat — в целых секундах, поэтому токен той же секунды проходит (например, выданный
+        // сразу после сброса пароля); выданные до сброса в ту же секунду отсекает отзыв их сессий ниже
+        iat, _ := claims["iat"].(float64)
+        if user.TokensValidAfter.Valid && int64(iat) < user.TokensValidAfter.Time.Unix() {
+            apperr.Abort(c, apperr.Unauthorized("session revoked, please log in again"))
+            return
+        }
+
+        // каждый токен привязан к сессии — её могли завершить с другого устройства
+        sid, _ := claims["sid"].(string)
+        if sid == "" {
+            apperr.Abort(c, apperr.Unauthorized("token is not bound to a session, log in again"))
+            return
+        }
+        if !checkSession(c, sid, user.ID) {
+            apperr.Abort(c, apperr.Unauthorized("session revoked, please log in again"))
+            return
+        }
+
+        mfa, _ := claims["mfa"].(bool)
+        if require2FAForAdmin && user.Role == RoleAdmin && !mfa && !mfaExempt(c.FullPath()) {
+            apperr.Abort(c, apperr.Forbidden("two-factor authentication required for admin accounts"))
+            return
+        }
+
+        c.Set("session_id", sid)
+        c.Set("token_iat", int64(iat)) // для StillAuthorized
+        c.Set("mfa", mfa)
+        authorize(c, user, requiredRole)
+    }
+}
+
+// authorize — общие проверки после того, как пользователь определён
+func authorize(c *gin.Context, user models.User, requiredRole string) {
+    if user.DeletedAt.Valid {
+        apperr.Abort(c, apperr.Unauthorized("account deleted"))
+        return
+    }
+    if user.IsBanned(time.Now()) {
+        apperr.Abort(c, bannedError(user))
+        return
+    }
+
+    // роль берём из БД, чтобы смена роли действовала сразу
+    if requiredRole != "" && user.Role != requiredRole {
+        apperr.Abort(c, apperr.Forbidden("insufficient permissions"))
+        return
+    }
+    c.Set("user_id", user.ID)
+    c.Set("username", user.Username)
+    c.Set("role", user.Role)
+    c.Next()
+}
+
+// mfaExempt — маршруты, доступные админу до подключения 2FA (с версией API и без)
+func mfaExempt(path string) bool {
+    if rest, ok := strings.CutPrefix(path, "/api/v1"); ok {
+        path = "/api" + rest
+    }
+    return strings.HasPrefix(path, "/api/auth/2fa/") || path == "/api/auth/profile"
+}
+
+func bannedError(user models.User) *apperr.Error {
+    details := gin.H{}
+    if user.BanReason.Valid {
+        details["reason"] = user.BanReason.String
+    }
+    if user.BannedUntil.Valid {
+        details["until"] = user.BannedUntil.Time
+    }
+    return apperr.New(http.StatusForbidden, apperr.CodeAccountBanned, "account suspended").WithDetails(details)
+}
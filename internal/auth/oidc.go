@@ -0,0 +1,444 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/config"
+	"uniconnect/internal/database"
+	"uniconnect/internal/models"
+	"uniconnect/internal/redis"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+)
+
+// Вход через университетский SSO (OpenID Connect, authorization code + PKCE).
+// Настройки — config.OIDCConfig; без IssuerURL SSO выключен.
+
+const (
+	oidcProvider = "university"
+	oidcStateTTL = 10 * time.Minute
+)
+
+type oidcSettings struct {
+	oauth2        oauth2.Config
+	verifier      *oidc.IDTokenVerifier
+	groupsClaim   string
+	roleMapping   map[string]string
+	autoProvision bool
+	syncRoles     bool
+	accounts      oidcAccounts
+}
+
+var sso *oidcSettings
+
+// oidcState хранится в Redis между редиректом на IdP и callback
+type oidcState struct {
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+	Device   string `json:"device"`
+}
+
+// InitOIDC подключается к IdP (discovery). Без IssuerURL SSO просто выключен.
+func InitOIDC(ctx context.Context, cfg config.OIDCConfig) error {
+	if cfg.IssuerURL == "" {
+		return nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+	if err != nil {
+		return fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+
+	for group, role := range cfg.RoleMapping {
+		if !ValidRole(role) {
+			return fmt.Errorf("oidc.role_mapping %s: unknown role %q", group, role)
+		}
+	}
+
+	groupsClaim := cfg.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = "groups"
+	}
+
+	sso = &oidcSettings{
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier:      provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		groupsClaim:   groupsClaim,
+		roleMapping:   cfg.RoleMapping,
+		autoProvision: cfg.AutoProvision,
+		syncRoles:     cfg.SyncRoles,
+		accounts:      pgOIDCAccounts{},
+	}
+	log.Println("OIDC SSO enabled:", cfg.IssuerURL)
+	return nil
+}
+
+// OIDCLoginHandler перенаправляет пользователя на страницу входа IdP
+func OIDCLoginHandler(c *gin.Context) {
+	if sso == nil {
+		apperr.Abort(c, apperr.NotFound("single sign-on is not configured"))
+		return
+	}
+
+	state := randomToken()
+	st := oidcState{
+		Verifier: oauth2.GenerateVerifier(),
+		Nonce:    randomToken(),
+		Device:   c.Query("device"),
+	}
+	data, _ := json.Marshal(st)
+	if err := redis.Rdb.Set(c.Request.Context(), "oidc:state:"+state, data, oidcStateTTL).Err(); err != nil {
+		apperr.Abort(c, apperr.Unavailable("could not start login", err))
+		return
+	}
+
+	url := sso.oauth2.AuthCodeURL(state, oauth2.S256ChallengeOption(st.Verifier), oidc.Nonce(st.Nonce))
+	c.Redirect(http.StatusFound, url)
+}
+
+// OIDCCallbackHandler обменивает code на токены, находит/создаёт пользователя и выдаёт наш JWT
+func OIDCCallbackHandler(c *gin.Context) {
+	if sso == nil {
+		apperr.Abort(c, apperr.NotFound("single sign-on is not configured"))
+		return
+	}
+
+	user, st, err := completeOIDCLogin(c)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if user.TOTPEnabled {
+		challenge, err := issueChallengeToken(user)
+		if err != nil {
+			apperr.Abort(c, apperr.Internal(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"two_factor_required": true, "challenge_token": challenge})
+		return
+	}
+
+	respondWithSession(c, user, st.Device, false)
+}
+
+// oidcIdentity — пользователь из проверенного id_token
+type oidcIdentity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Role              string // по группам из oidc.role_mapping; пустая — ни одна группа не подошла
+}
+
+// completeOIDCLogin проверяет ответ IdP (state, code, id_token, nonce) и находит пользователя,
+// которому можно выдать сессию
+func completeOIDCLogin(c *gin.Context) (models.User, oidcState, error) {
+	ctx := c.Request.Context()
+	id, st, err := verifyOIDCCallback(c)
+	if err != nil {
+		return models.User{}, st, err
+	}
+
+	user, err := linkOIDCUser(ctx, id)
+	if errors.Is(err, errNoLinkedAccount) {
+		return user, st, apperr.Forbidden("no UniConnect account is linked to this identity")
+	}
+	return user, st, err
+}
+
+// verifyOIDCCallback: state одноразовый, code обменивается с PKCE verifier,
+// id_token проверяется по JWKS IdP и должен нести nonce из OIDCLoginHandler
+func verifyOIDCCallback(c *gin.Context) (oidcIdentity, oidcState, error) {
+	var st oidcState
+	if e := c.Query("error"); e != "" {
+		return oidcIdentity{}, st, apperr.Unauthorized("identity provider error: " + e).WithDetails(gin.H{"description": c.Query("error_description")})
+	}
+
+	ctx := c.Request.Context()
+	data, err := redis.Rdb.GetDel(ctx, "oidc:state:"+c.Query("state")).Bytes()
+	if err != nil || json.Unmarshal(data, &st) != nil {
+		return oidcIdentity{}, st, apperr.BadRequest("invalid or expired state")
+	}
+
+	token, err := sso.oauth2.Exchange(ctx, c.Query("code"), oauth2.VerifierOption(st.Verifier))
+	if err != nil {
+		return oidcIdentity{}, st, apperr.Unauthorized("code exchange failed")
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return oidcIdentity{}, st, apperr.Unauthorized("no id_token in response")
+	}
+	idToken, err := sso.verifier.Verify(ctx, rawIDToken)
+	if err != nil || idToken.Nonce != st.Nonce {
+		return oidcIdentity{}, st, apperr.Unauthorized("invalid id_token")
+	}
+
+	var claims struct {
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		PreferredUsername string `json:"preferred_username"`
+	}
+	var raw map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil || idToken.Claims(&raw) != nil {
+		return oidcIdentity{}, st, apperr.Unauthorized("invalid id_token claims")
+	}
+	return oidcIdentity{
+		Subject:           idToken.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		PreferredUsername: claims.PreferredUsername,
+		Role:              mapGroupsToRole(stringList(raw[sso.groupsClaim])),
+	}, st, nil
+}
+
+var errNoLinkedAccount = errors.New("no linked account")
+
+// linkOIDCUser: сначала ищем привязку по sub, затем по подтверждённому email,
+// иначе (если разрешено) создаём нового пользователя. По неподтверждённому email
+// существующий аккаунт не привязывается.
+func linkOIDCUser(ctx context.Context, id oidcIdentity) (models.User, error) {
+	accounts := sso.accounts
+	userID, err := accounts.UserIDBySubject(ctx, id.Subject)
+	switch {
+	case err == nil:
+		return acceptOIDCUser(ctx, userID, id, false)
+	case !errors.Is(err, sql.ErrNoRows):
+		return models.User{}, err
+	}
+
+	if id.Email == "" {
+		return models.User{}, errNoLinkedAccount
+	}
+
+	userID, err = accounts.UserIDByEmail(ctx, id.Email)
+	switch {
+	case err == nil && id.EmailVerified:
+		return acceptOIDCUser(ctx, userID, id, true)
+	case err == nil:
+		// email занят, но IdP его не подтвердил — чужой аккаунт не отдаём
+		return models.User{}, errNoLinkedAccount
+	case !errors.Is(err, sql.ErrNoRows):
+		return models.User{}, err
+	}
+
+	if !sso.autoProvision {
+		return models.User{}, errNoLinkedAccount
+	}
+	role := id.Role
+	if role == "" {
+		role = RoleStudent
+	}
+
+	// пароль случайный: такой пользователь входит только через SSO, пока не сбросит пароль
+	hashed, err := bcrypt.GenerateFromPassword([]byte(randomToken()), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+	username, err := freeUsername(ctx, id.PreferredUsername, id.Email)
+	if err != nil {
+		return models.User{}, err
+	}
+	userID, err = accounts.CreateUser(ctx, username, id.Email, string(hashed), role)
+	if err != nil {
+		return models.User{}, err
+	}
+	if err := accounts.LinkIdentity(ctx, userID, id.Subject, id.Email); err != nil {
+		return models.User{}, err
+	}
+	return accounts.User(ctx, userID)
+}
+
+// acceptOIDCUser проверяет, что найденному аккаунту можно войти через SSO, и только после
+// этого привязывает identity (link) и синхронизирует роль: сервисный или забаненный
+// аккаунт не должен получить ни привязку, ни новую роль
+func acceptOIDCUser(ctx context.Context, userID int, id oidcIdentity, link bool) (models.User, error) {
+	accounts := sso.accounts
+	user, err := accounts.User(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.IsService {
+		return models.User{}, apperr.Forbidden("service accounts cannot use single sign-on")
+	}
+	if user.IsBanned(time.Now()) {
+		return models.User{}, bannedError(user)
+	}
+
+	if link {
+		if err := accounts.LinkIdentity(ctx, userID, id.Subject, id.Email); err != nil {
+			return models.User{}, err
+		}
+	}
+	if err := syncOIDCRole(ctx, userID, id.Role); err != nil {
+		return models.User{}, err
+	}
+	return accounts.User(ctx, userID)
+}
+
+// syncOIDCRole — при oidc.sync_roles роль пользователя следует за группами IdP.
+// Ни одна группа не подошла — роль по умолчанию: исключённый из групп админ не остаётся админом.
+func syncOIDCRole(ctx context.Context, userID int, role string) error {
+	if !sso.syncRoles {
+		return nil
+	}
+	if role == "" {
+		role = RoleStudent
+	}
+	return sso.accounts.SetRole(ctx, userID, role)
+}
+
+// oidcAccounts — пользователи и привязки SSO-идентичностей (в сервере — Postgres).
+// "Не найдено" — sql.ErrNoRows.
+type oidcAccounts interface {
+	UserIDBySubject(ctx context.Context, subject string) (int, error)
+	UserIDByEmail(ctx context.Context, email string) (int, error)
+	UsernameTaken(ctx context.Context, username string) (bool, error)
+	CreateUser(ctx context.Context, username, email, passwordHash, role string) (int, error)
+	LinkIdentity(ctx context.Context, userID int, subject, email string) error
+	SetRole(ctx context.Context, userID int, role string) error
+	User(ctx context.Context, id int) (models.User, error)
+}
+
+type pgOIDCAccounts struct{}
+
+func (pgOIDCAccounts) UserIDBySubject(ctx context.Context, subject string) (int, error) {
+	var userID int
+	err := database.DB.GetContext(ctx, &userID, `SELECT user_id FROM user_identities WHERE provider=$1 AND subject=$2`, oidcProvider, subject)
+	return userID, err
+}
+
+func (pgOIDCAccounts) UserIDByEmail(ctx context.Context, email string) (int, error) {
+	var userID int
+	err := database.DB.GetContext(ctx, &userID, `SELECT id FROM users WHERE lower(email)=lower($1)`, email)
+	return userID, err
+}
+
+func (pgOIDCAccounts) UsernameTaken(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := database.DB.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM users WHERE username=$1)`, username)
+	return exists, err
+}
+
+func (pgOIDCAccounts) CreateUser(ctx context.Context, username, email, passwordHash, role string) (int, error) {
+	var userID int
+	err := database.DB.GetContext(ctx, &userID, `
+		INSERT INTO users (username, email, password, password_set, role) VALUES ($1, $2, $3, false, $4)
+		RETURNING id
+	`, username, email, passwordHash, role)
+	return userID, err
+}
+
+func (pgOIDCAccounts) LinkIdentity(ctx context.Context, userID int, subject, email string) error {
+	_, err := database.DB.ExecContext(ctx, `
+		INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4)
+	`, userID, oidcProvider, subject, email)
+	return err
+}
+
+func (pgOIDCAccounts) SetRole(ctx context.Context, userID int, role string) error {
+	_, err := database.DB.ExecContext(ctx, `UPDATE users SET role=$1, updated_at=now() WHERE id=$2 AND role<>$1`, role, userID)
+	return err
+}
+
+func (pgOIDCAccounts) User(ctx context.Context, id int) (models.User, error) {
+	return loadUser(ctx, id)
+}
+
+var usernameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// freeUsername подбирает свободное имя на основе preferred_username или email
+func freeUsername(ctx context.Context, preferred, email string) (string, error) {
+	base := preferred
+	if base == "" {
+		base, _, _ = strings.Cut(email, "@")
+	}
+	base = usernameUnsafe.ReplaceAllString(base, "")
+	if base == "" {
+		base = "user"
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	name := base
+	for i := 2; i < 100; i++ {
+		exists, err := sso.accounts.UsernameTaken(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return name, nil
+		}
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	return base + "-" + randomToken()[:6], nil
+}
+
+// Чем правее роль, тем больше прав; при нескольких совпавших группах берём старшую
+var roleRank = []string{RoleStudent, RoleTeacher, RoleGroupAdmin, RoleModerator, RoleAdmin}
+
+func mapGroupsToRole(groups []string) string {
+	best := -1
+	for _, g := range groups {
+		role, ok := sso.roleMapping[g]
+		if !ok {
+			continue
+		}
+		for i, r := range roleRank {
+			if r == role && i > best {
+				best = i
+			}
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return roleRank[best]
+}
+
+func stringList(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, x := range t {
+			if s, ok := x.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func randomToken() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
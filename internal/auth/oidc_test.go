@@ -0,0 +1,479 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/config"
+	"uniconnect/internal/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ───────────────────────────────
+// MOCK IdP: discovery, JWKS, token endpoint
+// ───────────────────────────────
+
+const mockClientID = "uniconnect"
+
+type mockIdP struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockGrant
+}
+
+// mockGrant — что IdP выдаст по коду авторизации
+type mockGrant struct {
+	challenge string // PKCE code_challenge из запроса авторизации
+	claims    jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, codes: map[string]mockGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                idp.URL,
+			"authorization_endpoint":                idp.URL + "/authorize",
+			"token_endpoint":                        idp.URL + "/token",
+			"jwks_uri":                              idp.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		pub := idp.key.PublicKey
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "alg": "RS256", "use": "sig", "kid": "mock",
+			"n": base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		grant, ok := idp.codes[r.FormValue("code")]
+		delete(idp.codes, r.FormValue("code"))
+		idp.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss": idp.URL,
+			"aud": mockClientID,
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}
+		for k, v := range grant.claims {
+			claims[k] = v
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "mock"
+		idToken, err := token.SignedString(idp.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "mock-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   60,
+			"id_token":     idToken,
+		})
+	})
+
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// authorize — пользователь вошёл на странице IdP: IdP запоминает код с claims
+// и возвращает адрес callback, как при редиректе из браузера
+func (idp *mockIdP) authorize(t *testing.T, loginRedirect string, claims jwt.MapClaims) string {
+	t.Helper()
+	u, err := url.Parse(loginRedirect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != mockClientID || q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("authorization request %s", loginRedirect)
+	}
+	if _, ok := claims["nonce"]; !ok {
+		claims["nonce"] = q.Get("nonce")
+	}
+
+	code := randomToken()
+	idp.mu.Lock()
+	idp.codes[code] = mockGrant{challenge: q.Get("code_challenge"), claims: claims}
+	idp.mu.Unlock()
+	return "/callback?" + url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+}
+
+// ───────────────────────────────
+// учётные записи в памяти вместо Postgres
+// ───────────────────────────────
+
+type fakeAccounts struct {
+	users      []models.User
+	identities map[string]int // subject → user_id
+}
+
+func newFakeAccounts(users ...models.User) *fakeAccounts {
+	return &fakeAccounts{users: users, identities: map[string]int{}}
+}
+
+func (f *fakeAccounts) UserIDBySubject(ctx context.Context, subject string) (int, error) {
+	if id, ok := f.identities[subject]; ok {
+		return id, nil
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (f *fakeAccounts) UserIDByEmail(ctx context.Context, email string) (int, error) {
+	for _, u := range f.users {
+		if strings.EqualFold(u.Email, email) {
+			return u.ID, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (f *fakeAccounts) UsernameTaken(ctx context.Context, username string) (bool, error) {
+	for _, u := range f.users {
+		if u.Username == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeAccounts) CreateUser(ctx context.Context, username, email, passwordHash, role string) (int, error) {
+	id := len(f.users) + 1
+	f.users = append(f.users, models.User{ID: id, Username: username, Email: email, Password: passwordHash, Role: role})
+	return id, nil
+}
+
+func (f *fakeAccounts) LinkIdentity(ctx context.Context, userID int, subject, email string) error {
+	f.identities[subject] = userID
+	return nil
+}
+
+func (f *fakeAccounts) SetRole(ctx context.Context, userID int, role string) error {
+	for i := range f.users {
+		if f.users[i].ID == userID {
+			f.users[i].Role = role
+		}
+	}
+	return nil
+}
+
+func (f *fakeAccounts) User(ctx context.Context, id int) (models.User, error) {
+	for _, u := range f.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return models.User{}, sql.ErrNoRows
+}
+
+// ───────────────────────────────
+// flow
+// ───────────────────────────────
+
+type oidcTest struct {
+	idp      *mockIdP
+	accounts *fakeAccounts
+	redis    *miniredis.Miniredis
+	router   *gin.Engine
+}
+
+func setupOIDC(t *testing.T, autoProvision bool, users ...models.User) *oidcTest {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ts := &oidcTest{idp: newMockIdP(t), accounts: newFakeAccounts(users...), redis: useMiniredis(t)}
+
+	err := InitOIDC(context.Background(), config.OIDCConfig{
+		IssuerURL:    ts.idp.URL,
+		ClientID:     mockClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://uniconnect.test/api/v1/auth/oidc/callback",
+		RoleMapping: map[string]string{
+			"students":  RoleStudent,
+			"staff":     RoleTeacher,
+			"it-admins": RoleAdmin,
+		},
+		AutoProvision: autoProvision,
+		SyncRoles:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sso.accounts = ts.accounts
+	t.Cleanup(func() { sso = nil })
+
+	// выдача сессии пишет в Postgres, поэтому callback в тесте отвечает найденным пользователем
+	ts.router = gin.New()
+	ts.router.Use(apperr.Middleware())
+	ts.router.GET("/login", OIDCLoginHandler)
+	ts.router.GET("/callback", func(c *gin.Context) {
+		user, _, err := completeOIDCLogin(c)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, user)
+	})
+	return ts
+}
+
+func (ts *oidcTest) get(t *testing.T, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+// login проходит /login и страницу IdP; возвращает адрес callback
+func (ts *oidcTest) login(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	w := ts.get(t, "/login")
+	if w.Code != http.StatusFound {
+		t.Fatalf("login: status %d: %s", w.Code, w.Body)
+	}
+	return ts.idp.authorize(t, w.Header().Get("Location"), claims)
+}
+
+// signIn — полный вход; возвращает пользователя или ответ с ошибкой
+func (ts *oidcTest) signIn(t *testing.T, claims jwt.MapClaims) (models.User, *httptest.ResponseRecorder) {
+	t.Helper()
+	w := ts.get(t, ts.login(t, claims))
+	var user models.User
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return user, w
+}
+
+func expectError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	var e struct {
+		Code string `json:"code"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &e)
+	if w.Code != status || e.Code != code {
+		t.Fatalf("got %d %q, want %d %q: %s", w.Code, e.Code, status, code, w.Body)
+	}
+}
+
+var alice = models.User{ID: 1, Username: "alice", Email: "Alice@uni.edu", Role: RoleStudent}
+
+func TestOIDCStateIsSingleUse(t *testing.T) {
+	ts := setupOIDC(t, false, alice)
+	ts.accounts.identities["sub-alice"] = alice.ID
+
+	callback := ts.login(t, jwt.MapClaims{"sub": "sub-alice"})
+	if w := ts.get(t, callback); w.Code != http.StatusOK {
+		t.Fatalf("first callback: %d %s", w.Code, w.Body)
+	}
+	// тот же state (и код) повторно — отказ ещё до обращения к IdP
+	expectError(t, ts.get(t, callback), http.StatusBadRequest, apperr.CodeBadRequest)
+
+	expectError(t, ts.get(t, "/callback?code=x&state=unknown"), http.StatusBadRequest, apperr.CodeBadRequest)
+}
+
+func TestOIDCRejectsNonceMismatch(t *testing.T) {
+	ts := setupOIDC(t, false, alice)
+	ts.accounts.identities["sub-alice"] = alice.ID
+
+	_, w := ts.signIn(t, jwt.MapClaims{"sub": "sub-alice", "nonce": "replayed-from-another-login"})
+	expectError(t, w, http.StatusUnauthorized, apperr.CodeUnauthorized)
+}
+
+func TestOIDCRejectsPKCEMismatch(t *testing.T) {
+	ts := setupOIDC(t, false, alice)
+	callback := ts.login(t, jwt.MapClaims{"sub": "sub-alice"})
+
+	// state подменён состоянием другого входа: verifier не совпадёт с code_challenge
+	other := ts.get(t, "/login")
+	state, _ := url.Parse(other.Header().Get("Location"))
+	u, _ := url.Parse(callback)
+	q := u.Query()
+	q.Set("state", state.Query().Get("state"))
+	expectError(t, ts.get(t, "/callback?"+q.Encode()), http.StatusUnauthorized, apperr.CodeUnauthorized)
+}
+
+func TestOIDCLinkBySubject(t *testing.T) {
+	ts := setupOIDC(t, false, alice)
+	ts.accounts.identities["sub-alice"] = alice.ID
+
+	// email у IdP другой, но привязка по sub важнее
+	user, w := ts.signIn(t, jwt.MapClaims{"sub": "sub-alice", "email": "a.smith@uni.edu", "email_verified": true, "groups": []string{"staff"}})
+	if w.Code != http.StatusOK || user.ID != alice.ID {
+		t.Fatalf("signed in as %+v: %s", user, w.Body)
+	}
+	if user.Role != RoleTeacher {
+		t.Errorf("role %q, want synced %q", user.Role, RoleTeacher)
+	}
+}
+
+func TestOIDCLinkByVerifiedEmail(t *testing.T) {
+	ts := setupOIDC(t, false, alice)
+
+	user, w := ts.signIn(t, jwt.MapClaims{"sub": "sub-new", "email": "alice@uni.edu", "email_verified": true, "groups": "it-admins"})
+	if w.Code != http.StatusOK || user.ID != alice.ID {
+		t.Fatalf("signed in as %+v: %s", user, w.Body)
+	}
+	if ts.accounts.identities["sub-new"] != alice.ID {
+		t.Errorf("identity not linked: %v", ts.accounts.identities)
+	}
+	// роль из групп применяется уже при первой привязке
+	if user.Role != RoleAdmin {
+		t.Errorf("role %q, want %q", user.Role, RoleAdmin)
+	}
+}
+
+// при sync_roles пользователь, которого убрали из всех групп, теряет роль
+func TestOIDCSyncDemotesWithoutGroups(t *testing.T) {
+	admin := models.User{ID: 1, Username: "root", Email: "root@uni.edu", Role: RoleAdmin}
+	ts := setupOIDC(t, false, admin)
+	ts.accounts.identities["sub-root"] = admin.ID
+
+	user, w := ts.signIn(t, jwt.MapClaims{"sub": "sub-root", "groups": []string{"alumni"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if user.Role != RoleStudent {
+		t.Fatalf("role %q, want %q", user.Role, RoleStudent)
+	}
+}
+
+// забаненный аккаунт отклоняется до привязки identity и смены роли
+func TestOIDCBannedAccountIsNotLinked(t *testing.T) {
+	banned := alice
+	banned.BannedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	ts := setupOIDC(t, false, banned)
+
+	_, w := ts.signIn(t, jwt.MapClaims{"sub": "sub-new", "email": "alice@uni.edu", "email_verified": true, "groups": "it-admins"})
+	expectError(t, w, http.StatusForbidden, apperr.CodeAccountBanned)
+	if len(ts.accounts.identities) != 0 || ts.accounts.users[0].Role != RoleStudent {
+		t.Fatalf("identities %v, role %q", ts.accounts.identities, ts.accounts.users[0].Role)
+	}
+}
+
+func TestOIDCServiceAccountKeepsRole(t *testing.T) {
+	bot := models.User{ID: 1, Username: "bot", Email: "bot@uni.edu", Role: RoleTeacher, IsService: true}
+	ts := setupOIDC(t, false, bot)
+	ts.accounts.identities["sub-bot"] = bot.ID
+
+	_, w := ts.signIn(t, jwt.MapClaims{"sub": "sub-bot", "groups": "it-admins"})
+	expectError(t, w, http.StatusForbidden, apperr.CodeForbidden)
+	if role := ts.accounts.users[0].Role; role != RoleTeacher {
+		t.Fatalf("role %q, want %q", role, RoleTeacher)
+	}
+}
+
+func TestOIDCRefusesUnverifiedEmail(t *testing.T) {
+	for _, autoProvision := range []bool{false, true} {
+		ts := setupOIDC(t, autoProvision, alice)
+
+		_, w := ts.signIn(t, jwt.MapClaims{"sub": "sub-mallory", "email": "alice@uni.edu", "email_verified": false})
+		expectError(t, w, http.StatusForbidden, apperr.CodeForbidden)
+		if len(ts.accounts.identities) != 0 || len(ts.accounts.users) != 1 {
+			t.Fatalf("auto_provision=%v: identities %v, users %d", autoProvision, ts.accounts.identities, len(ts.accounts.users))
+		}
+	}
+}
+
+func TestOIDCAutoProvision(t *testing.T) {
+	ts := setupOIDC(t, true, alice)
+
+	user, w := ts.signIn(t, jwt.MapClaims{
+		"sub": "sub-bob", "email": "bob@uni.edu", "email_verified": true,
+		"preferred_username": "alice", "groups": []string{"students"},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if user.ID != 2 || user.Email != "bob@uni.edu" || user.Username != "alice2" || user.Role != RoleStudent {
+		t.Fatalf("provisioned %+v", user)
+	}
+	if ts.accounts.identities["sub-bob"] != user.ID {
+		t.Errorf("identity not linked: %v", ts.accounts.identities)
+	}
+
+	// повторный вход — тот же пользователь по sub
+	again, _ := ts.signIn(t, jwt.MapClaims{"sub": "sub-bob", "email": "bob@uni.edu", "email_verified": true})
+	if again.ID != user.ID || len(ts.accounts.users) != 2 {
+		t.Fatalf("second sign-in %+v, %d users", again, len(ts.accounts.users))
+	}
+}
+
+func TestOIDCWithoutAutoProvision(t *testing.T) {
+	ts := setupOIDC(t, false, alice)
+
+	_, w := ts.signIn(t, jwt.MapClaims{"sub": "sub-bob", "email": "bob@uni.edu", "email_verified": true})
+	expectError(t, w, http.StatusForbidden, apperr.CodeForbidden)
+}
+
+func TestOIDCRoleMappingPicksHighest(t *testing.T) {
+	sso = &oidcSettings{roleMapping: map[string]string{
+		"students":   RoleStudent,
+		"staff":      RoleTeacher,
+		"moderators": RoleModerator,
+		"it-admins":  RoleAdmin,
+	}}
+	t.Cleanup(func() { sso = nil })
+
+	cases := []struct {
+		groups []string
+		want   string
+	}{
+		{[]string{"students"}, RoleStudent},
+		{[]string{"staff", "students"}, RoleTeacher},
+		{[]string{"students", "moderators", "staff"}, RoleModerator},
+		{[]string{"it-admins", "moderators"}, RoleAdmin},
+		{[]string{"alumni"}, ""},
+		{nil, ""},
+	}
+	for _, tc := range cases {
+		if got := mapGroupsToRole(tc.groups); got != tc.want {
+			t.Errorf("mapGroupsToRole(%v) = %q, want %q", tc.groups, got, tc.want)
+		}
+	}
+
+	if got := stringList([]interface{}{"staff", 7, "it-admins"}); len(got) != 2 {
+		t.Errorf("stringList: %v", got)
+	}
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"sort"
+	"uniconnect/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Роли пользователей (users.role)
+const (
+	RoleStudent    = "student"
+	RoleTeacher    = "teacher"
+	RoleModerator  = "moderator"
+	RoleGroupAdmin = "group_admin"
+	RoleAdmin      = "admin"
+)
+
+// Именованные права. Хендлеры проверяют права, а не конкретные роли.
+const (
+	PermPostEditAny      = "post.edit.any"
+	PermPostDeleteAny    = "post.delete.any"
+	PermCommentDeleteAny = "comment.delete.any"
+	PermGroupCreate      = "group.create"
+	PermGroupApprove     = "group.approve"
+	PermUserView         = "user.view"
+	PermUserBan          = "user.ban"
+	PermUserManage       = "user.manage"
+	PermUserRoleAssign   = "user.role.assign"
+	PermAdminDashboard   = "admin.dashboard"
+
+	PermServiceAccountManage = "service_account.manage"
+)
+
+var rolePermissions = map[string][]string{
+	RoleStudent: {},
+	RoleTeacher: {},
+	RoleModerator: {
+		PermPostEditAny,
+		PermPostDeleteAny,
+		PermCommentDeleteAny,
+		PermUserView,
+		PermUserBan,
+		PermAdminDashboard,
+	},
+	RoleGroupAdmin: {
+		PermGroupCreate,
+		PermGroupApprove,
+	},
+	RoleAdmin: {
+		PermPostEditAny,
+		PermPostDeleteAny,
+		PermCommentDeleteAny,
+		PermGroupCreate,
+		PermGroupApprove,
+		PermUserView,
+		PermUserBan,
+		PermUserManage,
+		PermUserRoleAssign,
+		PermAdminDashboard,
+		PermServiceAccountManage,
+	},
+}
+
+// ValidRole сообщает, известна ли роль
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission проверяет, есть ли у роли право
+func HasPermission(role, perm string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions возвращает копию таблицы ролей и прав (для админки)
+func RolePermissions() map[string][]string {
+	out := make(map[string][]string, len(rolePermissions))
+	for role, perms := range rolePermissions {
+		list := append([]string{}, perms...)
+		sort.Strings(list)
+		out[role] = list
+	}
+	return out
+}
+
+// RequirePermission пропускает запрос, только если у роли пользователя есть право.
+// Ставится после AuthMiddleware.
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if role == "" {
+			apperr.Abort(c, apperr.Unauthorized("unauthorized"))
+			return
+		}
+		if !HasPermission(role, perm) {
+			apperr.Abort(c, apperr.Forbidden("insufficient permissions").WithDetails(gin.H{"required": perm}))
+			return
+		}
+		c.Next()
+	}
+}
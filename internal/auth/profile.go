@@ -1,31 +1,39 @@
-package auth
-
-import (
-    "net/http"
-    "uniconnect/internal/database"
-    "github.com/gin-gonic/gin"
-)
-
-type ProfileResponse struct {
-    ID       int    `json:"id"`
-    Username string `json:"username"`
-    Email    string `json:"email"`
-    Role     string `json:"role"`
-}
-
-func ProfileHandler(c *gin.Context) {
-    username := c.GetString("username")
-    if username == "" {
-        c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-        return
-    }
-
-    var user ProfileResponse
-    err := database.DB.Get(&user, "SELECT id, username, email, role FROM users WHERE username=$1", username)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
-        return
-    }
-
-    c.JSON(http.StatusOK, user)
-}
+package auth
+
+import (
+    "net/http"
+    "uniconnect/internal/apperr"
+
+    "github.com/gin-gonic/gin"
+)
+
+type ProfileResponse struct {
+    ID       int    `json:"id"`
+    Username string `json:"username"`
+    Email    string `json:"email"`
+    Role     string `json:"role"`
+
+    TwoFactorEnabled bool `json:"two_factor_enabled"`
+}
+
+func (h *Handler) Profile(c *gin.Context) {
+    userID := c.GetInt("user_id")
+    if userID == 0 {
+        apperr.Abort(c, apperr.Unauthorized("unauthorized"))
+        return
+    }
+
+    user, err := h.Users.ByID(c.Request.Context(), userID)
+    if err != nil {
+        apperr.Abort(c, apperr.OrNotFound(err, "user not found"))
+        return
+    }
+
+    c.JSON(http.StatusOK, ProfileResponse{
+        ID:               user.ID,
+        Username:         user.Username,
+        Email:            user.Email,
+        Role:             user.Role,
+        TwoFactorEnabled: user.TOTPEnabled,
+    })
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"testing"
+
+	"uniconnect/internal/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// useMiniredis подключает redis.Rdb к miniredis на время теста
+func useMiniredis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	prev := redis.Rdb
+	redis.Rdb = goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		redis.Rdb.Close()
+		redis.Rdb = prev
+	})
+	return mr
+}
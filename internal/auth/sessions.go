@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/database"
+	"uniconnect/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Время жизни токена; сессия продлевается при каждом /refresh
+const tokenTTL = 24 * time.Hour
+
+// Session — место, где пользователь вошёл в аккаунт
+type Session struct {
+	ID         string     `db:"id" json:"id"`
+	UserID     int        `db:"user_id" json:"-"`
+	Device     string     `db:"device" json:"device"`
+	IP         string     `db:"ip" json:"ip"`
+	UserAgent  string     `db:"user_agent" json:"user_agent"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	LastSeenAt time.Time  `db:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"-"`
+	Current    bool       `db:"-" json:"current"`
+}
+
+// respondWithSession создаёт сессию, выдаёт привязанный к ней токен и отвечает клиенту
+func respondWithSession(c *gin.Context, user models.User, device string, mfa bool) {
+	if device == "" {
+		device = deviceFromUserAgent(c.Request.UserAgent())
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		apperr.Abort(c, apperr.Internal(err))
+		return
+	}
+	sid := hex.EncodeToString(buf)
+
+	_, err := database.DB.ExecContext(c.Request.Context(), `
+		INSERT INTO user_sessions (id, user_id, device, ip, user_agent, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, sid, user.ID, device, c.ClientIP(), c.Request.UserAgent(), time.Now().Add(tokenTTL))
+	if err != nil {
+		apperr.Abort(c, apperr.Internal(err))
+		return
+	}
+
+	tokenString, err := issueToken(user, sid, mfa)
+	if err != nil {
+		apperr.Abort(c, apperr.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": tokenString, "session_id": sid})
+}
+
+// issueToken выдаёт токен доступа, привязанный к сессии; mfa=true, если вход подтверждён вторым фактором
+func issueToken(user models.User, sid string, mfa bool) (string, error) {
+	return signToken(jwt.MapClaims{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+		"sid":      sid,
+		"mfa":      mfa,
+		"iat":      time.Now().Unix(),
+		"exp":      time.Now().Add(tokenTTL).Unix(),
+	})
+}
+
+// checkSession — сессия токена должна существовать и не быть отозванной
+func checkSession(c *gin.Context, sid string, userID int) bool {
+	var s Session
+	err := database.DB.GetContext(c.Request.Context(), &s, `SELECT * FROM user_sessions WHERE id=$1 AND user_id=$2`, sid, userID)
+	if err != nil || s.RevokedAt != nil || s.ExpiresAt.Before(time.Now()) {
+		return false
+	}
+
+	// last_seen обновляем не чаще раза в минуту
+	if time.Since(s.LastSeenAt) > time.Minute || s.IP != c.ClientIP() {
+		_, _ = database.DB.ExecContext(c.Request.Context(), `UPDATE user_sessions SET last_seen_at=now(), ip=$1 WHERE id=$2`, c.ClientIP(), sid)
+	}
+	return true
+}
+
+// RefreshHandler выдаёт новый токен для текущей сессии и продлевает её
+func RefreshHandler(c *gin.Context) {
+	sid := c.GetString("session_id")
+	if sid == "" {
+		apperr.Abort(c, apperr.BadRequest("token is not bound to a session, log in again"))
+		return
+	}
+
+	user, err := loadUser(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		apperr.Abort(c, apperr.Unauthorized("unauthorized"))
+		return
+	}
+
+	_, err = database.DB.ExecContext(c.Request.Context(), `
+		UPDATE user_sessions SET expires_at=$1, last_seen_at=now(), ip=$2, user_agent=$3 WHERE id=$4
+	`, time.Now().Add(tokenTTL), c.ClientIP(), c.Request.UserAgent(), sid)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	tokenString, err := issueToken(user, sid, c.GetBool("mfa"))
+	if err != nil {
+		apperr.Abort(c, apperr.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": tokenString, "session_id": sid})
+}
+
+// ListSessionsHandler — активные сессии текущего пользователя
+func ListSessionsHandler(c *gin.Context) {
+	sessions := []Session{}
+	err := database.DB.SelectContext(c.Request.Context(), &sessions, `
+		SELECT * FROM user_sessions
+		WHERE user_id=$1 AND revoked_at IS NULL AND expires_at > now()
+		ORDER BY last_seen_at DESC
+	`, c.GetInt("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	current := c.GetString("session_id")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSessionHandler — выход на другом устройстве (или на текущем)
+func RevokeSessionHandler(c *gin.Context) {
+	res, err := database.DB.ExecContext(c.Request.Context(), `
+		UPDATE user_sessions SET revoked_at=now()
+		WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL
+	`, c.Param("id"), c.GetInt("user_id"))
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		apperr.Abort(c, apperr.NotFound("session not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+// LogoutHandler завершает текущую сессию
+func LogoutHandler(c *gin.Context) {
+	sid := c.GetString("session_id")
+	if sid != "" {
+		_, err := database.DB.ExecContext(c.Request.Context(), `UPDATE user_sessions SET revoked_at=now() WHERE id=$1 AND revoked_at IS NULL`, sid)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// RevokeAllSessions завершает все сессии пользователя (сброс пароля, принудительный выход)
+func RevokeAllSessions(userID int) error {
+	_, err := database.DB.Exec(`UPDATE user_sessions SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL`, userID)
+	return err
+}
+
+// deviceFromUserAgent — грубое название устройства, если клиент не прислал своё
+func deviceFromUserAgent(ua string) string {
+	platforms := []struct{ marker, name string }{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Macintosh", "macOS"},
+		{"Linux", "Linux"},
+	}
+	for _, p := range platforms {
+		if strings.Contains(ua, p.marker) {
+			return p.name
+		}
+	}
+	if ua == "" {
+		return "unknown"
+	}
+	return "other"
+}
+
+// StillAuthorized повторяет проверки AuthMiddleware для уже открытого соединения (WebSocket):
+// пока оно живёт, сессию могли завершить, пользователя — разлогинить везде, забанить или удалить,
+// а API-ключ — отозвать. nil — соединение можно держать дальше.
+func StillAuthorized(c *gin.Context) *apperr.Error {
+	ctx := c.Request.Context()
+	if id, ok := c.Get("api_key_id"); ok {
+		var key APIKey
+		if err := database.DB.GetContext(ctx, &key, `SELECT * FROM api_keys WHERE id=$1`, id); err != nil {
+			return apperr.Unauthorized("invalid API key")
+		}
+		if key.RevokedAt != nil || (key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now())) {
+			return apperr.Unauthorized("API key revoked or expired")
+		}
+	}
+
+	user, err := loadUser(ctx, c.GetInt("user_id"))
+	if err != nil {
+		return apperr.Unauthorized("invalid token")
+	}
+	if user.DeletedAt.Valid {
+		return apperr.Unauthorized("account deleted")
+	}
+	if user.IsBanned(time.Now()) {
+		return bannedError(user)
+	}
+
+	if sid := c.GetString("session_id"); sid != "" {
+		iat := c.GetInt64("token_iat")
+		if user.TokensValidAfter.Valid && iat < user.TokensValidAfter.Time.Unix() {
+			return apperr.Unauthorized("session revoked, please log in again")
+		}
+		if !checkSession(c, sid, user.ID) {
+			return apperr.Unauthorized("session revoked, please log in again")
+		}
+	}
+	return nil
+}
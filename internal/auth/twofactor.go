@@ -0,0 +1,356 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/database"
+	"uniconnect/internal/models"
+	"uniconnect/internal/redis"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type twoFactorCodeReq struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// TwoFactorSetupHandler генерирует новый секрет и ссылку для QR-кода.
+// 2FA включается только после подтверждения кода в TwoFactorEnableHandler.
+func TwoFactorSetupHandler(c *gin.Context) {
+	user, err := loadUser(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "user not found"))
+		return
+	}
+	if user.TOTPEnabled {
+		apperr.Abort(c, apperr.Conflict("two-factor authentication already enabled"))
+		return
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		apperr.Abort(c, apperr.Internal(err))
+		return
+	}
+
+	_, err = database.DB.ExecContext(c.Request.Context(), `UPDATE users SET totp_secret=$1, totp_last_counter=0, updated_at=now() WHERE id=$2`, secret, user.ID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":       secret,
+		"otpauth_url":  totpProvisioningURI(secret, user.Username),
+		"instructions": "Scan the otpauth_url as a QR code, then confirm with POST /api/auth/2fa/enable",
+	})
+}
+
+// TwoFactorEnableHandler подтверждает секрет и выдаёт коды восстановления
+func TwoFactorEnableHandler(c *gin.Context) {
+	var req twoFactorCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		apperr.Abort(c, apperr.BadRequest("code required"))
+		return
+	}
+
+	user, err := loadUser(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "user not found"))
+		return
+	}
+	if user.TOTPEnabled {
+		apperr.Abort(c, apperr.Conflict("two-factor authentication already enabled"))
+		return
+	}
+	if !user.TOTPSecret.Valid {
+		apperr.Abort(c, apperr.BadRequest("call POST /api/auth/2fa/setup first"))
+		return
+	}
+
+	counter, ok := validateTOTP(user.TOTPSecret.String, req.Code, user.TOTPLastCounter, time.Now())
+	if !ok {
+		apperr.Abort(c, apperr.Unauthorized("invalid code"))
+		return
+	}
+
+	_, err = database.DB.ExecContext(c.Request.Context(), `UPDATE users SET totp_enabled=true, totp_last_counter=$1, updated_at=now() WHERE id=$2`, counter, user.ID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(c.Request.Context(), user.ID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "two-factor authentication enabled, log in again to get a verified token",
+		"recovery_codes": codes,
+	})
+}
+
+// TwoFactorDisableHandler отключает 2FA (нужны пароль и код или код восстановления)
+func TwoFactorDisableHandler(c *gin.Context) {
+	var req struct {
+		Password string `json:"password" binding:"required"`
+		twoFactorCodeReq
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Invalid(err))
+		return
+	}
+
+	user, err := loadUser(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "user not found"))
+		return
+	}
+	if !user.TOTPEnabled {
+		apperr.Abort(c, apperr.BadRequest("two-factor authentication is not enabled"))
+		return
+	}
+	// с украденной сессией код можно было бы подбирать здесь
+	if !allowTwoFactorAttempt(c, user.ID) {
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		apperr.Abort(c, apperr.Unauthorized("invalid credentials"))
+		return
+	}
+
+	ok, err := verifySecondFactor(c.Request.Context(), user, req.twoFactorCodeReq)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if !ok {
+		apperr.Abort(c, apperr.Unauthorized("invalid code"))
+		return
+	}
+
+	tx, err := database.DB.BeginTxx(c.Request.Context(), nil)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`UPDATE users SET totp_secret=NULL, totp_enabled=false, totp_last_counter=0, updated_at=now() WHERE id=$1`, user.ID); err == nil {
+		_, err = tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id=$1`, user.ID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	redis.Rdb.Del(c.Request.Context(), twoFactorAttemptsKey(user.ID))
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodesHandler выдаёт новый набор кодов, старые перестают работать
+func RegenerateRecoveryCodesHandler(c *gin.Context) {
+	var req twoFactorCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		apperr.Abort(c, apperr.BadRequest("code required"))
+		return
+	}
+
+	user, err := loadUser(c.Request.Context(), c.GetInt("user_id"))
+	if err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "user not found"))
+		return
+	}
+	if !user.TOTPEnabled {
+		apperr.Abort(c, apperr.BadRequest("two-factor authentication is not enabled"))
+		return
+	}
+
+	if !allowTwoFactorAttempt(c, user.ID) {
+		return
+	}
+
+	// только TOTP: кодом восстановления нельзя перевыпустить коды восстановления
+	ok, err := verifySecondFactor(c.Request.Context(), user, twoFactorCodeReq{Code: req.Code})
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if !ok {
+		apperr.Abort(c, apperr.Unauthorized("invalid code"))
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(c.Request.Context(), user.ID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	redis.Rdb.Del(c.Request.Context(), twoFactorAttemptsKey(user.ID))
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// LoginTwoFactorHandler — второй шаг входа: challenge-токен + TOTP или код восстановления
+func LoginTwoFactorHandler(c *gin.Context) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Device         string `json:"device"`
+		twoFactorCodeReq
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Invalid(err))
+		return
+	}
+
+	claims, err := parseToken(req.ChallengeToken)
+	if err != nil {
+		apperr.Abort(c, apperr.Unauthorized("invalid or expired challenge"))
+		return
+	}
+	if purpose, _ := claims["purpose"].(string); purpose != challengePurpose {
+		apperr.Abort(c, apperr.Unauthorized("invalid or expired challenge"))
+		return
+	}
+	userID, _ := claims["user_id"].(float64)
+
+	user, err := loadUser(c.Request.Context(), int(userID))
+	if err != nil || !user.TOTPEnabled {
+		apperr.Abort(c, apperr.Unauthorized("invalid or expired challenge"))
+		return
+	}
+	if user.IsBanned(time.Now()) {
+		apperr.Abort(c, bannedError(user))
+		return
+	}
+
+	if !allowTwoFactorAttempt(c, user.ID) {
+		return
+	}
+
+	ok, err := verifySecondFactor(c.Request.Context(), user, req.twoFactorCodeReq)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if !ok {
+		apperr.Abort(c, apperr.Unauthorized("invalid code"))
+		return
+	}
+
+	redis.Rdb.Del(c.Request.Context(), twoFactorAttemptsKey(user.ID))
+	respondWithSession(c, user, req.Device, true)
+}
+
+// maxTwoFactorAttempts — сколько кодов пользователь может ввести: при входе, отключении 2FA
+// и перевыпуске кодов восстановления. Счётчик общий для всех этих мест и всех challenge
+// (новый вход по паролю не обнуляет его) и живёт challengeTTL с последней попытки;
+// после успешной проверки сбрасывается.
+const maxTwoFactorAttempts = 5
+
+// allowTwoFactorAttempt учитывает попытку; если они исчерпаны, отвечает 429 и возвращает false
+func allowTwoFactorAttempt(c *gin.Context, userID int) bool {
+	allowed, err := countTwoFactorAttempt(c.Request.Context(), userID)
+	if err != nil {
+		apperr.Abort(c, apperr.Unavailable("could not verify code", err))
+		return false
+	}
+	if !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(challengeTTL.Seconds())))
+		apperr.Abort(c, apperr.TooManyRequests("too many invalid codes, try again later"))
+		return false
+	}
+	return true
+}
+
+func twoFactorAttemptsKey(userID int) string {
+	return "2fa:attempts:" + strconv.Itoa(userID)
+}
+
+// countTwoFactorAttempt учитывает попытку до проверки кода (параллельные запросы тоже считаются);
+// false — попытки исчерпаны, и challenge-токены пользователя больше не принимаются
+func countTwoFactorAttempt(ctx context.Context, userID int) (bool, error) {
+	key := twoFactorAttemptsKey(userID)
+	var incr *goredis.IntCmd
+	_, err := redis.Rdb.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, challengeTTL)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return incr.Val() <= maxTwoFactorAttempts, nil
+}
+
+func loadUser(ctx context.Context, id int) (models.User, error) {
+	var user models.User
+	err := database.DB.GetContext(ctx, &user, "SELECT * FROM users WHERE id=$1", id)
+	return user, err
+}
+
+// verifySecondFactor проверяет TOTP-код или погашает код восстановления
+func verifySecondFactor(ctx context.Context, user models.User, req twoFactorCodeReq) (bool, error) {
+	switch {
+	case req.Code != "":
+		if !user.TOTPSecret.Valid {
+			return false, nil
+		}
+		counter, ok := validateTOTP(user.TOTPSecret.String, req.Code, user.TOTPLastCounter, time.Now())
+		if !ok {
+			return false, nil
+		}
+		// условие по счётчику защищает от повторного использования кода параллельными запросами
+		res, err := database.DB.ExecContext(ctx, `UPDATE users SET totp_last_counter=$1 WHERE id=$2 AND totp_last_counter < $1`, counter, user.ID)
+		if err != nil {
+			return false, err
+		}
+		n, err := res.RowsAffected()
+		return n == 1, err
+
+	case req.RecoveryCode != "":
+		res, err := database.DB.ExecContext(ctx, `
+			UPDATE user_recovery_codes SET used_at=now()
+			WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL
+		`, user.ID, hashRecoveryCode(req.RecoveryCode))
+		if err != nil {
+			return false, err
+		}
+		n, err := res.RowsAffected()
+		return n == 1, err
+	}
+	return false, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, userID int) ([]string, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		if _, err := tx.Exec(`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hashRecoveryCode(code)); err != nil {
+			return nil, err
+		}
+	}
+	return codes, tx.Commit()
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestTwoFactorAttemptsAreLimited(t *testing.T) {
+	mr := useMiniredis(t)
+	ctx := context.Background()
+
+	for i := 1; i <= maxTwoFactorAttempts; i++ {
+		allowed, err := countTwoFactorAttempt(ctx, 7)
+		if err != nil || !allowed {
+			t.Fatalf("attempt %d: allowed=%v err=%v", i, allowed, err)
+		}
+	}
+	// новые challenge того же пользователя не дают новых попыток
+	if allowed, err := countTwoFactorAttempt(ctx, 7); err != nil || allowed {
+		t.Fatalf("attempt %d: allowed=%v err=%v", maxTwoFactorAttempts+1, allowed, err)
+	}
+	if allowed, _ := countTwoFactorAttempt(ctx, 8); !allowed {
+		t.Fatal("attempts of another user are counted separately")
+	}
+
+	if mr.TTL(twoFactorAttemptsKey(7)) <= 0 {
+		t.Fatal("attempt counter has no expiry")
+	}
+}
+
+// отключение 2FA и перевыпуск кодов расходуют те же попытки, что и вход
+func TestTwoFactorSettingsShareAttemptLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useMiniredis(t)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := database.DB
+	database.DB = sqlx.NewDb(db, "postgres")
+	t.Cleanup(func() { database.DB.Close(); database.DB = prev })
+
+	for i := 0; i < maxTwoFactorAttempts; i++ {
+		countTwoFactorAttempt(context.Background(), 7)
+	}
+
+	r := gin.New()
+	r.Use(apperr.Middleware(), func(c *gin.Context) { c.Set("user_id", 7) })
+	r.POST("/2fa/disable", TwoFactorDisableHandler)
+	r.POST("/2fa/recovery-codes", RegenerateRecoveryCodesHandler)
+	for _, path := range []string{"/2fa/disable", "/2fa/recovery-codes"} {
+		mock.ExpectQuery(`SELECT \* FROM users WHERE id=\$1`).WithArgs(7).WillReturnRows(
+			sqlmock.NewRows([]string{"id", "username", "totp_enabled", "totp_secret"}).AddRow(7, "alice", true, "JBSWY3DPEHPK3PXP"))
+
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"password":"secret","code":"123456"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+			t.Errorf("%s: status %d: %s", path, w.Code, w.Body)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
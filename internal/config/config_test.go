@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.JWTSecret = strings.Repeat("s", MinJWTSecretLen)
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// без секрета HS256 не запускается, а не подписывает случайным ключом
+func TestJWTSecretRequired(t *testing.T) {
+	for _, secret := range []string{"", "change-me"} {
+		cfg := Default()
+		cfg.Auth.JWTSecret = secret
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), "auth.jwt_secret must be at least 32 bytes") {
+			t.Errorf("secret %q: %v", secret, err)
+		}
+	}
+}
+
+func TestUnknownRateLimitGroup(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("JWT_SECRET", strings.Repeat("s", MinJWTSecretLen))
+	t.Setenv("RATE_LIMIT_RULES", "post=30/1m,ws=5/10s")
+	_, err := Load("")
+	if err == nil || !strings.Contains(err.Error(), `unknown group "post"`) {
+		t.Fatalf("Load: %v", err)
+	}
+	if strings.Contains(err.Error(), `"ws"`) {
+		t.Fatalf("known group reported: %v", err)
+	}
+}
+
+func TestSyncRolesNeedsMapping(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.JWTSecret = strings.Repeat("s", MinJWTSecretLen)
+	cfg.OIDC.SyncRoles = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "oidc.role_mapping is required") {
+		t.Fatalf("Validate: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package groups
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	m.Run()
+}
+
+// serve выполняет запрос от имени пользователя userID с ролью role;
+// маршруты и права — как в cmd/server/routes.go
+func serve(h *Handler, userID int, role, method, path, body string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(apperr.Middleware(), func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("role", role)
+	})
+	r.POST("/admin/groups", auth.RequirePermission(auth.PermGroupCreate), h.CreateGroup)
+	r.GET("/admin/groups/requests", auth.RequirePermission(auth.PermGroupApprove), h.ListJoinRequests)
+	r.POST("/admin/groups/requests/:id/approve", auth.RequirePermission(auth.PermGroupApprove), h.ApproveJoinRequest)
+	r.DELETE("/admin/groups/requests/:id", auth.RequirePermission(auth.PermGroupApprove), h.RemoveJoinRequest)
+	r.POST("/groups/:groupId/join", h.RequestJoin)
+	r.GET("/groups", h.ListGroups)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func expect(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status %d, want %d: %s", w.Code, status, w.Body)
+	}
+	if code == "" {
+		return
+	}
+	var e struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.Code != code {
+		t.Fatalf("code %q, want %q: %s", e.Code, code, w.Body)
+	}
+}
+
+func TestCreateGroup(t *testing.T) {
+	h := NewHandler(memory.NewGroupRepo())
+
+	w := serve(h, 1, auth.RoleGroupAdmin, http.MethodPost, "/admin/groups", `{"name":"CS-101","members":[3,2,3]}`)
+	expect(t, w, http.StatusCreated, "")
+	var g models.Group
+	if err := json.Unmarshal(w.Body.Bytes(), &g); err != nil || g.ID != 1 || len(g.Members) != 2 {
+		t.Fatalf("created group %+v: %v", g, err)
+	}
+
+	expect(t, serve(h, 2, auth.RoleStudent, http.MethodPost, "/admin/groups", `{"name":"mine"}`),
+		http.StatusForbidden, apperr.CodeForbidden)
+	expect(t, serve(h, 1, auth.RoleGroupAdmin, http.MethodPost, "/admin/groups", `{}`),
+		http.StatusUnprocessableEntity, apperr.CodeValidation)
+}
+
+func TestRequestJoin(t *testing.T) {
+	h := NewHandler(memory.NewGroupRepo())
+	expect(t, serve(h, 1, auth.RoleGroupAdmin, http.MethodPost, "/admin/groups", `{"name":"CS-101","members":[2]}`),
+		http.StatusCreated, "")
+
+	expect(t, serve(h, 5, auth.RoleStudent, http.MethodPost, "/groups/1/join", ""), http.StatusCreated, "")
+	// повторная заявка и заявка участника группы — 409
+	expect(t, serve(h, 5, auth.RoleStudent, http.MethodPost, "/groups/1/join", ""), http.StatusConflict, apperr.CodeConflict)
+	expect(t, serve(h, 2, auth.RoleStudent, http.MethodPost, "/groups/1/join", ""), http.StatusConflict, apperr.CodeConflict)
+	expect(t, serve(h, 5, auth.RoleStudent, http.MethodPost, "/groups/99/join", ""), http.StatusNotFound, apperr.CodeNotFound)
+}
+
+func TestApproveJoinRequest(t *testing.T) {
+	h := NewHandler(memory.NewGroupRepo())
+	expect(t, serve(h, 1, auth.RoleGroupAdmin, http.MethodPost, "/admin/groups", `{"name":"CS-101"}`), http.StatusCreated, "")
+	expect(t, serve(h, 5, auth.RoleStudent, http.MethodPost, "/groups/1/join", ""), http.StatusCreated, "")
+
+	expect(t, serve(h, 5, auth.RoleStudent, http.MethodPost, "/admin/groups/requests/1/approve", ""),
+		http.StatusForbidden, apperr.CodeForbidden)
+	expect(t, serve(h, 1, auth.RoleGroupAdmin, http.MethodPost, "/admin/groups/requests/1/approve", ""), http.StatusOK, "")
+	expect(t, serve(h, 1, auth.RoleGroupAdmin, http.MethodPost, "/admin/groups/requests/1/approve", ""),
+		http.StatusNotFound, apperr.CodeNotFound)
+	expect(t, serve(h, 1, auth.RoleGroupAdmin, http.MethodDelete, "/admin/groups/requests/1", ""),
+		http.StatusNotFound, apperr.CodeNotFound)
+
+	// студент видит только свои группы, и теперь он в группе
+	w := serve(h, 5, auth.RoleStudent, http.MethodGet, "/groups", "")
+	expect(t, w, http.StatusOK, "")
+	var list []models.Group
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 1 || list[0].Members[0] != 5 {
+		t.Fatalf("groups %s", w.Body)
+	}
+	expect(t, serve(h, 5, auth.RoleStudent, http.MethodPost, "/groups/1/join", ""), http.StatusConflict, apperr.CodeConflict)
+}
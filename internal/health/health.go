@@ -0,0 +1,144 @@
+// Package health — проверки живости/готовности и информация о сборке
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+	"uniconnect/internal/database"
+	"uniconnect/internal/logging"
+	"uniconnect/internal/migrator"
+	"uniconnect/internal/redis"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Заполняются при сборке:
+//
+//	go build -ldflags "-X uniconnect/internal/health.Version=1.2.0 -X uniconnect/internal/health.Commit=$(git rev-parse --short HEAD)"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+var startedAt = time.Now()
+
+// ожидаемая версия схемы — последняя миграция (встроенная или из каталога)
+var migrationsDir string
+
+// Configure задаёт каталог миграций, по которому считается ожидаемая версия схемы;
+// пустой — встроенные в бинарник
+func Configure(dir string) {
+	migrationsDir = dir
+}
+
+const checkTimeout = 2 * time.Second
+
+// LivenessHandler — GET /healthz: процесс жив и обслуживает запросы
+func LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadinessHandler — GET /readyz: БД и Redis отвечают, миграции применены.
+// Эндпоинт открыт без авторизации, поэтому в ответе только "ok"/"fail" по каждой проверке;
+// текст ошибки (адреса, имена баз) пишется в лог.
+func ReadinessHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+	defer cancel()
+
+	checks := gin.H{}
+	ready := true
+	fail := func(name string, err error) {
+		logging.FromContext(c).Warn("readiness check failed", "check", name, "error", err)
+		checks[name] = "fail"
+		ready = false
+	}
+
+	if err := database.DB.PingContext(ctx); err != nil {
+		fail("database", err)
+	} else {
+		checks["database"] = "ok"
+	}
+
+	if err := redis.Rdb.Ping(ctx).Err(); err != nil {
+		fail("redis", err)
+	} else {
+		checks["redis"] = "ok"
+	}
+
+	if err := checkMigrations(ctx); err != nil {
+		fail("migrations", err)
+	} else {
+		checks["migrations"] = "ok"
+	}
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{"ready": ready, "checks": checks})
+}
+
+// VersionHandler — GET /version: версия сборки
+func VersionHandler(c *gin.Context) {
+	info := gin.H{
+		"version":    Version,
+		"commit":     Commit,
+		"build_time": BuildTime,
+		"started_at": startedAt.UTC(),
+		"uptime":     time.Since(startedAt).Round(time.Second).String(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info["go_version"] = bi.GoVersion
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if Commit == "" {
+					info["commit"] = s.Value
+				}
+			case "vcs.time":
+				if BuildTime == "" {
+					info["build_time"] = s.Value
+				}
+			case "vcs.modified":
+				info["dirty"] = s.Value == "true"
+			}
+		}
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+// checkMigrations сверяет schema_migrations с последней миграцией в каталоге
+func checkMigrations(ctx context.Context) error {
+	expected, err := latestMigration(migrationsDir)
+	if err != nil {
+		return err
+	}
+
+	var current struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+	if err := database.DB.GetContext(ctx, &current, `SELECT version, dirty FROM schema_migrations LIMIT 1`); err != nil {
+		return fmt.Errorf("schema version unknown: %w", err)
+	}
+	if current.Dirty {
+		return fmt.Errorf("schema version %d is dirty", current.Version)
+	}
+	if current.Version != expected {
+		return fmt.Errorf("schema version %d, expected %d", current.Version, expected)
+	}
+	return nil
+}
+
+// latestMigration — наибольшая версия среди миграций
+func latestMigration(dir string) (int64, error) {
+	migrations, err := migrator.Load(dir)
+	if err != nil {
+		return 0, err
+	}
+	return int64(migrations[len(migrations)-1].Version), nil
+}
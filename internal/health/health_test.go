@@ -0,0 +1,51 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"uniconnect/internal/database"
+	"uniconnect/internal/redis"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// в ответе /readyz нет текста ошибок: только ok/fail по каждой проверке
+func TestReadinessHidesErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevDB, prevRdb := database.DB, redis.Rdb
+	database.DB = sqlx.NewDb(db, "postgres")
+	redis.Rdb = goredis.NewClient(&goredis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() {
+		database.DB.Close()
+		redis.Rdb.Close()
+		database.DB, redis.Rdb = prevDB, prevRdb
+	})
+	mock.ExpectPing().WillReturnError(errors.New("dial tcp 10.0.0.5:5432: connection refused"))
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+		WillReturnError(errors.New(`pq: database "uniconnect_prod" does not exist`))
+
+	r := gin.New()
+	r.GET("/readyz", ReadinessHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	want := `{"checks":{"database":"fail","migrations":"fail","redis":"ok"},"ready":false}`
+	if got := strings.TrimSpace(w.Body.String()); got != want {
+		t.Fatalf("body %s, want %s", got, want)
+	}
+}
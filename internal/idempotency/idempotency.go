@@ -0,0 +1,184 @@
+// Package idempotency — заголовок Idempotency-Key для создающих запросов:
+// повтор запроса с тем же ключом получает сохранённый в Redis ответ, а не создаёт новую запись.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"uniconnect/internal/apiversion"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/redis"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	Header       = "Idempotency-Key"
+	ReplayHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+	// lockTTL — сколько держится отметка «запрос выполняется», если сервер упал, не дождавшись ответа
+	lockTTL = time.Minute
+)
+
+// ttl — сколько хранится ответ; повтор после этого срока выполнится заново
+var ttl = 24 * time.Hour
+
+// Configure задаёт время хранения ответов
+func Configure(d time.Duration) {
+	if d > 0 {
+		ttl = d
+	}
+}
+
+// entry — запись в Redis: сначала «выполняется», после успешного ответа — сам ответ
+type entry struct {
+	Done        bool   `json:"done"`
+	Fingerprint string `json:"fingerprint"` // метод, маршрут, параметры пути и тело запроса
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Middleware делает маршрут идемпотентным для запросов с Idempotency-Key
+// (ставится после AuthMiddleware: ключи разделены по пользователям).
+//
+//   - первый запрос выполняется, успешный (2xx) ответ сохраняется на ttl;
+//     после ошибки ключ освобождается, и повтор выполнится заново
+//   - повтор с тем же ключом и тем же запросом получает сохранённый ответ
+//     с заголовком Idempotent-Replayed: true
+//   - повтор, пока первый запрос ещё выполняется, — 409
+//   - тот же ключ с другим телом или на другой маршрут — 422
+//     (/api/posts/ и /api/v1/posts/ — один маршрут)
+//
+// Без заголовка запрос выполняется как обычно; если Redis недоступен — тоже.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(Header)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			apperr.Abort(c, apperr.BadRequest("Idempotency-Key must be at most 255 characters"))
+			return
+		}
+		if redis.Rdb == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			apperr.Abort(c, apperr.BadRequest("malformed request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		rkey := "idem:" + strconv.Itoa(c.GetInt("user_id")) + ":" + key
+		fingerprint := fingerprintOf(c, body)
+
+		lock, _ := json.Marshal(entry{Fingerprint: fingerprint})
+		acquired, err := redis.Rdb.SetNX(ctx, rkey, lock, lockTTL).Result()
+		if err != nil {
+			c.Next()
+			return
+		}
+		if !acquired {
+			replay(c, rkey, fingerprint)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+
+		// клиент мог уже отвалиться (ради этого всё и затевалось) — ответ сохраняем всё равно
+		ctx = context.WithoutCancel(ctx)
+		// ошибки (apperr.Abort) отрисовываются позже, в apperr.Middleware, — ответа ещё нет
+		status := rec.Status()
+		if !rec.Written() || len(c.Errors) > 0 || status < 200 || status >= 300 {
+			redis.Rdb.Del(ctx, rkey)
+			return
+		}
+		saved, _ := json.Marshal(entry{
+			Done:        true,
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Location:    rec.Header().Get("Location"),
+			Body:        rec.body.Bytes(),
+		})
+		redis.Rdb.Set(ctx, rkey, saved, ttl)
+	}
+}
+
+// replay отвечает на повтор: сохранённым ответом или ошибкой
+func replay(c *gin.Context, rkey, fingerprint string) {
+	raw, err := redis.Rdb.Get(c.Request.Context(), rkey).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		// первый запрос только что завершился ошибкой и освободил ключ
+		c.Header("Retry-After", "1")
+		apperr.Abort(c, apperr.Conflict("request with this Idempotency-Key was just processed, retry"))
+		return
+	}
+	var e entry
+	if err != nil || json.Unmarshal(raw, &e) != nil {
+		apperr.Abort(c, apperr.Unavailable("idempotency store is unavailable", err))
+		return
+	}
+
+	switch {
+	case e.Fingerprint != fingerprint:
+		apperr.Abort(c, apperr.Unprocessable("Idempotency-Key was already used for a different request"))
+	case !e.Done:
+		c.Header("Retry-After", "1")
+		apperr.Abort(c, apperr.Conflict("request with this Idempotency-Key is still being processed"))
+	default:
+		c.Header(ReplayHeader, "true")
+		if e.Location != "" {
+			c.Header("Location", e.Location)
+		}
+		c.Data(e.Status, e.ContentType, e.Body)
+		c.Abort()
+	}
+}
+
+// fingerprintOf — отпечаток запроса. Маршрут берётся шаблоном без различия между
+// /api/... и /api/v1/...: повтор на другой адрес того же обработчика — тот же запрос.
+func fingerprintOf(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + apiversion.Route(c) + "\n"))
+	for _, p := range c.Params {
+		h.Write([]byte(p.Key + "=" + p.Value + "\n"))
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder пишет ответ клиенту и копию — для сохранения
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
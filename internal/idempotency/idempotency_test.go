@@ -0,0 +1,173 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"uniconnect/internal/apiversion"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	m.Run()
+}
+
+func useMiniredis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	prev := redis.Rdb
+	redis.Rdb = goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		redis.Rdb.Close()
+		redis.Rdb = prev
+	})
+	return mr
+}
+
+// server — POST /api/v1/posts/:id/comments и устаревший /api/posts/:id/comments с одним обработчиком
+type server struct {
+	*gin.Engine
+	calls   atomic.Int32
+	fail    atomic.Bool   // обработчик отвечает ошибкой
+	block   chan struct{} // если не nil, обработчик ждёт, пока канал закроют
+	started chan struct{} // закрывается, когда обработчик начал работу
+}
+
+func newServer() *server {
+	s := &server{Engine: gin.New()}
+	s.Use(apperr.Middleware(), func(c *gin.Context) { c.Set("user_id", 7) })
+	handler := func(c *gin.Context) {
+		n := s.calls.Add(1)
+		if s.block != nil {
+			close(s.started)
+			<-s.block
+		}
+		if s.fail.Load() {
+			apperr.Abort(c, apperr.Unavailable("database is down", nil))
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": n, "post_id": c.Param("id")})
+	}
+	s.POST("/api/v1/posts/:id/comments", Middleware(), handler)
+	legacy := s.Group("/api", apiversion.Deprecated(apiversion.Deprecation{From: "/api", To: "/api/v1"}))
+	legacy.POST("/posts/:id/comments", Middleware(), handler)
+	return s
+}
+
+func (s *server) post(path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(Header, key)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+func TestReplay(t *testing.T) {
+	useMiniredis(t)
+	s := newServer()
+
+	first := s.post("/api/v1/posts/1/comments", "k1", `{"content":"hi"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", first.Code, first.Body)
+	}
+	// повтор — в том числе на устаревший адрес того же маршрута
+	for _, path := range []string{"/api/v1/posts/1/comments", "/api/posts/1/comments"} {
+		w := s.post(path, "k1", `{"content":"hi"}`)
+		if w.Code != http.StatusCreated || w.Body.String() != first.Body.String() || w.Header().Get(ReplayHeader) != "true" {
+			t.Fatalf("%s: replay %d %q %s", path, w.Code, w.Header().Get(ReplayHeader), w.Body)
+		}
+	}
+	if n := s.calls.Load(); n != 1 {
+		t.Fatalf("handler ran %d times", n)
+	}
+
+	// другой ключ — новый запрос
+	if w := s.post("/api/v1/posts/1/comments", "k2", `{"content":"hi"}`); w.Header().Get(ReplayHeader) != "" || s.calls.Load() != 2 {
+		t.Fatalf("new key replayed: %s", w.Body)
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	useMiniredis(t)
+	s := newServer()
+	s.post("/api/v1/posts/1/comments", "k1", `{"content":"hi"}`)
+
+	for _, tc := range []struct{ path, body string }{
+		{"/api/v1/posts/1/comments", `{"content":"bye"}`}, // другое тело
+		{"/api/v1/posts/2/comments", `{"content":"hi"}`},  // другой пост
+	} {
+		w := s.post(tc.path, "k1", tc.body)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s %s: status %d: %s", tc.path, tc.body, w.Code, w.Body)
+		}
+	}
+	if n := s.calls.Load(); n != 1 {
+		t.Fatalf("handler ran %d times", n)
+	}
+}
+
+func TestInProgress(t *testing.T) {
+	useMiniredis(t)
+	s := newServer()
+	s.block, s.started = make(chan struct{}), make(chan struct{})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- s.post("/api/v1/posts/1/comments", "k1", `{"content":"hi"}`) }()
+	<-s.started
+
+	w := s.post("/api/v1/posts/1/comments", "k1", `{"content":"hi"}`)
+	if w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("while in progress: status %d: %s", w.Code, w.Body)
+	}
+
+	close(s.block)
+	if w := <-done; w.Code != http.StatusCreated {
+		t.Fatalf("first request: status %d: %s", w.Code, w.Body)
+	}
+}
+
+// после ошибки ключ свободен: повтор выполняется заново
+func TestReleasedOnError(t *testing.T) {
+	mr := useMiniredis(t)
+	s := newServer()
+	s.fail.Store(true)
+
+	if w := s.post("/api/v1/posts/1/comments", "k1", `{"content":"hi"}`); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if mr.Exists("idem:7:k1") {
+		t.Fatal("key kept after an error")
+	}
+
+	s.fail.Store(false)
+	w := s.post("/api/v1/posts/1/comments", "k1", `{"content":"hi"}`)
+	if w.Code != http.StatusCreated || w.Header().Get(ReplayHeader) != "" || s.calls.Load() != 2 {
+		t.Fatalf("retry after error: %d %s", w.Code, w.Body)
+	}
+	// сохранённый ответ живёт ttl, а не lockTTL
+	if got := mr.TTL("idem:7:k1"); got <= lockTTL || got > ttl {
+		t.Fatalf("stored response ttl %v", got)
+	}
+}
+
+func TestKeyLength(t *testing.T) {
+	useMiniredis(t)
+	s := newServer()
+	w := s.post("/api/v1/posts/1/comments", strings.Repeat("k", maxKeyLength+1), `{}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if n := s.calls.Load(); n != 0 {
+		t.Fatalf("handler ran %d times", n)
+	}
+}
@@ -0,0 +1,169 @@
+// Package logging — структурированные логи (log/slog) и request ID
+package logging
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"uniconnect/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// Setup настраивает slog по умолчанию. Стандартный log тоже пишет через него,
+// так что старые log.Println попадают в тот же JSON-поток.
+func Setup(cfg config.LogConfig) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return fmt.Errorf("log level: %w", err)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch cfg.Format {
+	case "text":
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	default:
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// RequestID берёт X-Request-ID из запроса (если он похож на ID) или создаёт новый
+// и возвращает его в ответе
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{8,128}$`)
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// FromContext — логгер с request_id (и trace_id, если запрос трассируется)
+func FromContext(c *gin.Context) *slog.Logger {
+	logger := slog.Default().With("request_id", c.GetString("request_id"))
+	if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+		logger = logger.With("trace_id", sc.TraceID().String())
+	}
+	return logger
+}
+
+// Middleware пишет строку лога на каждый запрос и добавляет request_id в JSON-ответы
+// с ошибкой. 5xx логируются как ошибки вместе с текстом ошибки, user_id и маршрутом.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		w := &errorBodyWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		c.Next()
+		// gin может дописать ответ после middleware (404/405) — уже без буфера
+		c.Writer = w.ResponseWriter
+
+		status := c.Writer.Status()
+		// в c.Errors лежит исходная ошибка (с текстом БД и т.п.), в теле ответа — только то, что видит клиент
+		errMsg := w.flush(c.GetString("request_id"))
+		if len(c.Errors) > 0 {
+			errMsg = c.Errors.Last().Error()
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", route,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"ip", c.ClientIP(),
+		}
+		if userID := c.GetInt("user_id"); userID != 0 {
+			attrs = append(attrs, "user_id", userID)
+		}
+
+		logger := FromContext(c)
+		switch {
+		case status >= 500:
+			logger.Error("request failed", append(attrs, "error", errMsg)...)
+		case status >= 400:
+			logger.Warn("request rejected", append(attrs, "error", errMsg)...)
+		default:
+			logger.Info("request", attrs...)
+		}
+	}
+}
+
+// errorBodyWriter придерживает тело ответов со статусом >= 400,
+// чтобы добавить в него request_id и взять текст ошибки для лога
+type errorBodyWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *errorBodyWriter) Write(b []byte) (int, error) {
+	if w.Status() >= 400 && !w.Written() {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *errorBodyWriter) WriteString(s string) (int, error) {
+	if w.Status() >= 400 && !w.Written() {
+		return w.buf.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// flush отправляет придержанное тело и возвращает текст ошибки из него ("message" или "error")
+func (w *errorBodyWriter) flush(requestID string) string {
+	if w.buf.Len() == 0 {
+		return ""
+	}
+	body := w.buf.Bytes()
+	var errMsg string
+
+	var obj map[string]json.RawMessage
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") && json.Unmarshal(body, &obj) == nil {
+		for _, key := range []string{"message", "error"} {
+			var s string
+			if json.Unmarshal(obj[key], &s) == nil && s != "" {
+				errMsg = s
+				break
+			}
+		}
+		if _, ok := obj["request_id"]; !ok && requestID != "" {
+			obj["request_id"], _ = json.Marshal(requestID)
+			if b, err := json.Marshal(obj); err == nil {
+				body = b
+			}
+		}
+	}
+
+	w.ResponseWriter.Write(body)
+	return errMsg
+}
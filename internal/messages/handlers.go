@@ -1,86 +1,93 @@
-package messages
-
-import (
-	"net/http"
-	"strconv"
-	"sync"
-	"sync/atomic"
-	"time"
-
-	"github.com/gin-gonic/gin"
-)
-
-type Message struct {
-	ID        string    `json:"id"`
-	ChatID    string    `json:"chat_id"`
-	SenderID  string    `json:"sender_id"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
-var (
-	messagesStore = make(map[string][]Message) // chatId -> messages
-	msgMu         sync.Mutex
-	msgSeq        int64
-)
-
-func getUserID(c *gin.Context) string {
-	// Пытаемся взять user из контекста, fallback на заголовок X-User-ID (в зависимости от реализации auth)
-	if v, ok := c.Get("user"); ok {
-		switch u := v.(type) {
-		case string:
-			return u
-		case int:
-			return strconv.Itoa(u)
-		}
-	}
-	if v, ok := c.Get("user_id"); ok {
-		return v.(string)
-	}
-	if h := c.GetHeader("X-User-ID"); h != "" {
-		return h
-	}
-	// По умолчанию пусто
-	return ""
-}
-
-type sendReq struct {
-	Content string `json:"content" binding:"required"`
-}
-
-func SendMessageHandler(c *gin.Context) {
-	chatId := c.Param("chatId")
-	var req sendReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "content required"})
-		return
-	}
-	sender := getUserID(c)
-	if sender == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	id := strconv.FormatInt(atomic.AddInt64(&msgSeq, 1), 10)
-	msg := Message{
-		ID:        id,
-		ChatID:    chatId,
-		SenderID:  sender,
-		Content:   req.Content,
-		CreatedAt: time.Now(),
-	}
-
-	msgMu.Lock()
-	messagesStore[chatId] = append(messagesStore[chatId], msg)
-	msgMu.Unlock()
-
-	c.JSON(http.StatusCreated, msg)
-}
-
-func ListMessagesHandler(c *gin.Context) {
-	chatId := c.Param("chatId")
-	msgMu.Lock()
-	list := messagesStore[chatId]
-	msgMu.Unlock()
-	c.JSON(http.StatusOK, list)
-}
+package messages
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler — личные сообщения. Чат — пара пользователей, chatId вида "6_7"
+// (тот же формат, что у /ws/private/:chatId).
+type Handler struct {
+	Messages repository.MessageRepo
+}
+
+func NewHandler(messages repository.MessageRepo) *Handler {
+	return &Handler{Messages: messages}
+}
+
+// Peer возвращает собеседника userID в чате вида "<user_id>_<user_id>"; в чужой чат доступа нет
+func Peer(chatID string, userID int) (int, error) {
+	a, b, found := strings.Cut(chatID, "_")
+	first, err1 := strconv.Atoi(a)
+	second, err2 := strconv.Atoi(b)
+	if !found || err1 != nil || err2 != nil {
+		return 0, apperr.BadRequest("chat ID must look like <user_id>_<user_id>")
+	}
+	switch userID {
+	case first:
+		return second, nil
+	case second:
+		return first, nil
+	}
+	return 0, apperr.Forbidden("you are not a member of this chat")
+}
+
+// chatPeer возвращает собеседника текущего пользователя или прерывает запрос
+func chatPeer(c *gin.Context, userID int) (int, bool) {
+	peer, err := Peer(c.Param("chatId"), userID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return 0, false
+	}
+	return peer, true
+}
+
+type sendReq struct {
+	Content string `json:"content" binding:"required"`
+}
+
+func (h *Handler) SendMessage(c *gin.Context) {
+	var req sendReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.Invalid(err))
+		return
+	}
+	sender := c.GetInt("user_id")
+	receiver, ok := chatPeer(c, sender)
+	if !ok {
+		return
+	}
+
+	msg := models.Message{
+		SenderID:   sender,
+		ReceiverID: receiver,
+		Content:    req.Content,
+	}
+	if err := h.Messages.Send(c.Request.Context(), &msg); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, msg)
+}
+
+func (h *Handler) ListMessages(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	peer, ok := chatPeer(c, userID)
+	if !ok {
+		return
+	}
+
+	list, err := h.Messages.Chat(c.Request.Context(), userID, peer)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
@@ -0,0 +1,82 @@
+package messages
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	m.Run()
+}
+
+// serve выполняет запрос от имени пользователя userID (как после AuthMiddleware)
+func serve(h *Handler, userID int, method, path, body string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(apperr.Middleware(), func(c *gin.Context) { c.Set("user_id", userID) })
+	r.POST("/messages/:chatId", h.SendMessage)
+	r.GET("/messages/:chatId", h.ListMessages)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func expect(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status %d, want %d: %s", w.Code, status, w.Body)
+	}
+	if code == "" {
+		return
+	}
+	var e struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.Code != code {
+		t.Fatalf("code %q, want %q: %s", e.Code, code, w.Body)
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	h := NewHandler(memory.NewMessageRepo())
+
+	w := serve(h, 6, http.MethodPost, "/messages/6_7", `{"content":"hi"}`)
+	expect(t, w, http.StatusCreated, "")
+	var msg models.Message
+	if err := json.Unmarshal(w.Body.Bytes(), &msg); err != nil || msg.SenderID != 6 || msg.ReceiverID != 7 {
+		t.Fatalf("sent message %+v: %v", msg, err)
+	}
+
+	// чужой чат
+	expect(t, serve(h, 8, http.MethodPost, "/messages/6_7", `{"content":"hi"}`), http.StatusForbidden, apperr.CodeForbidden)
+	expect(t, serve(h, 6, http.MethodPost, "/messages/6", `{"content":"hi"}`), http.StatusBadRequest, apperr.CodeBadRequest)
+	expect(t, serve(h, 6, http.MethodPost, "/messages/6_7", `{}`), http.StatusUnprocessableEntity, apperr.CodeValidation)
+}
+
+func TestListMessages(t *testing.T) {
+	h := NewHandler(memory.NewMessageRepo())
+	expect(t, serve(h, 6, http.MethodPost, "/messages/6_7", `{"content":"hi"}`), http.StatusCreated, "")
+	expect(t, serve(h, 7, http.MethodPost, "/messages/6_7", `{"content":"hello"}`), http.StatusCreated, "")
+	expect(t, serve(h, 6, http.MethodPost, "/messages/6_8", `{"content":"other chat"}`), http.StatusCreated, "")
+
+	w := serve(h, 7, http.MethodGet, "/messages/6_7", "")
+	expect(t, w, http.StatusOK, "")
+	var list []models.Message
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 2 {
+		t.Fatalf("chat %s", w.Body)
+	}
+
+	expect(t, serve(h, 8, http.MethodGet, "/messages/6_7", ""), http.StatusForbidden, apperr.CodeForbidden)
+}
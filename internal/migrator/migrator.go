@@ -0,0 +1,195 @@
+// Package migrator — миграции схемы (golang-migrate) для cmd/migrate и старта сервера.
+// По умолчанию используются миграции, встроенные в бинарник (пакет migrations);
+// непустой dir (database.migrations_dir) — каталог на диске.
+// Перед любым действием набор проверяется: golang-migrate молча пропускает файлы
+// с опечатками в имени и версии без пары up/down.
+package migrator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"uniconnect/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// DefaultDir — куда create пишет новые файлы, если каталог не задан
+const DefaultDir = "migrations"
+
+// <version>_<name>.up.sql / <version>_<name>.down.sql
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration — пара файлов одной версии
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// source — встроенные миграции или каталог на диске
+func source(dir string) (fs.FS, string) {
+	if dir == "" {
+		return migrations.FS, "embedded migrations"
+	}
+	return os.DirFS(dir), dir
+}
+
+// Load читает миграции и проверяет их: каждое имя файла должно разбираться,
+// у каждой версии ровно одно имя и оба файла up и down. Возвращает миграции по возрастанию версии.
+func Load(dir string) ([]Migration, error) {
+	fsys, label := source(dir)
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrations: %w", err)
+	}
+
+	byVersion := map[uint]*Migration{}
+	var errs []error
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		if filepath.Ext(name) == ".go" { // migrations.go с go:embed
+			continue
+		}
+		m := fileName.FindStringSubmatch(name)
+		if m == nil {
+			errs = append(errs, fmt.Errorf("%s: malformed name, want <version>_<name>.up.sql or .down.sql", name))
+			continue
+		}
+		v, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: bad version: %w", name, err))
+			continue
+		}
+		version := uint(v)
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			errs = append(errs, fmt.Errorf("%s: version %d is already used by %q", name, version, mig.Name))
+			continue
+		}
+		if m[3] == "up" {
+			mig.Up = name
+		} else {
+			mig.Down = name
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		switch {
+		case mig.Up == "":
+			errs = append(errs, fmt.Errorf("%s: no matching .up.sql", mig.Down))
+		case mig.Down == "":
+			errs = append(errs, fmt.Errorf("%s: no matching .down.sql", mig.Up))
+		}
+		out = append(out, *mig)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid %s:\n%w", label, err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no migrations found in %s", label)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// New проверяет каталог и открывает golang-migrate; закрывать через Close
+func New(databaseURL, dir string) (*migrate.Migrate, error) {
+	if _, err := Load(dir); err != nil {
+		return nil, err
+	}
+	fsys, _ := source(dir)
+	src, err := iofs.New(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", src, databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	m.Log = logger{}
+	return m, nil
+}
+
+// Up применяет все новые миграции (database.auto_migrate при старте сервера)
+func Up(databaseURL, dir string) error {
+	m, err := New(databaseURL, dir)
+	if err != nil {
+		return err
+	}
+	defer Close(m)
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Close закрывает источник и соединение с БД
+func Close(m *migrate.Migrate) error {
+	srcErr, dbErr := m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+var unsafeName = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Create создаёт пустую пару файлов в каталоге (по умолчанию DefaultDir; встроятся
+// при следующей сборке). Версия — время UTC (20060102150405), но всегда больше последней существующей.
+func Create(dir, name string, now time.Time) (up, down string, err error) {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	name = strings.Trim(unsafeName.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", errors.New("migration name is empty")
+	}
+
+	existing, err := Load(dir)
+	if err != nil {
+		return "", "", err
+	}
+	version, _ := strconv.ParseUint(now.UTC().Format("20060102150405"), 10, 64)
+	if last := uint64(existing[len(existing)-1].Version); version <= last {
+		version = last + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%d_%s", version, name))
+	up, down = base+".up.sql", base+".down.sql"
+	for _, f := range []string{up, down} {
+		file, err := os.OpenFile(f, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return "", "", err
+		}
+		file.Close()
+	}
+	return up, down, nil
+}
+
+// logger — вывод golang-migrate в общий slog
+type logger struct{}
+
+func (logger) Printf(format string, v ...interface{}) {
+	slog.Info(strings.TrimSpace(fmt.Sprintf(format, v...)), "component", "migrate")
+}
+
+func (logger) Verbose() bool { return false }
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type Group struct {
+	ID        int       `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Members   []int     `db:"-" json:"members"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// JoinRequest — заявка студента на вступление в группу
+type JoinRequest struct {
+	ID        int       `db:"id" json:"id"`
+	GroupID   int       `db:"group_id" json:"group_id"`
+	UserID    int       `db:"user_id" json:"user_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
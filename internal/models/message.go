@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Message — личное сообщение; чат определяется парой sender/receiver
+type Message struct {
+	ID         int       `db:"id" json:"id"`
+	SenderID   int       `db:"sender_id" json:"sender_id"`
+	ReceiverID int       `db:"receiver_id" json:"receiver_id"`
+	Content    string    `db:"content" json:"content"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
@@ -1,15 +1,31 @@
-package models
-
-import (
-    "time"
-)
-
-type User struct {
-    ID        int       `db:"id" json:"id"`
-    Username  string    `db:"username" json:"username"`
-    Email     string    `db:"email" json:"email"`
-    Password  string    `db:"password" json:"-"`
-    Role      string    `db:"role" json:"role"`
-    CreatedAt time.Time `db:"created_at" json:"created_at"`
-    UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
-}
+package models
+
+import (
+    "database/sql"
+    "time"
+)
+
+type User struct {
+    ID               int            `db:"id" json:"id"`
+    Username         string         `db:"username" json:"username"`
+    Email            string         `db:"email" json:"email"`
+    Password         string         `db:"password" json:"-"`
+    PasswordSet      bool           `db:"password_set" json:"-"` // false — пароль случайный, выдан при входе через SSO
+    Role             string         `db:"role" json:"role"`
+    IsService        bool           `db:"is_service" json:"is_service"`
+    TOTPSecret       sql.NullString `db:"totp_secret" json:"-"`
+    TOTPEnabled      bool           `db:"totp_enabled" json:"totp_enabled"`
+    TOTPLastCounter  int64          `db:"totp_last_counter" json:"-"`
+    BannedAt         sql.NullTime   `db:"banned_at" json:"-"`
+    BannedUntil      sql.NullTime   `db:"banned_until" json:"-"`
+    BanReason        sql.NullString `db:"ban_reason" json:"-"`
+    TokensValidAfter sql.NullTime   `db:"tokens_valid_after" json:"-"`
+    DeletedAt        sql.NullTime   `db:"deleted_at" json:"-"`
+    CreatedAt        time.Time      `db:"created_at" json:"created_at"`
+    UpdatedAt        time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// IsBanned — бан действует, если он бессрочный или ещё не истёк
+func (u User) IsBanned(now time.Time) bool {
+    return u.BannedAt.Valid && (!u.BannedUntil.Valid || u.BannedUntil.Time.After(now))
+}
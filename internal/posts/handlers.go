@@ -1,149 +1,161 @@
-package posts
-
-import (
-	"net/http"
-	"strconv"
-	"time"
-	"uniconnect/internal/database"
-	"uniconnect/internal/redis"
-
-	"github.com/gin-gonic/gin"
-)
-
-func CreatePostHandler(c *gin.Context) {
-	var post Post
-	if err := c.ShouldBindJSON(&post); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	username := c.GetString("username")
-	var authorID int
-	err := database.DB.Get(&authorID, "SELECT id FROM users WHERE username=$1", username)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "author not found"})
-		return
-	}
-
-	post.AuthorID = authorID
-	post.CreatedAt = time.Now()
-	post.UpdatedAt = time.Now()
-
-	res, err := database.DB.NamedExec(`
-    INSERT INTO posts (title, content, category, author_id, created_at, updated_at)
-    VALUES (:title, :content, :category, :author_id, :created_at, :updated_at)
-`, &post)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	id, _ := res.LastInsertId()
-	post.ID = int(id)
-
-	redis.Rdb.LPush(redis.Ctx, "notifications", username+" created a post: "+post.Title)
-
-	c.JSON(http.StatusOK, post)
-}
-
-// ----------------- LIST -----------------
-func ListPostsHandler(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset := (page - 1) * limit
-
-	var posts []Post
-	err := database.DB.Select(&posts, "SELECT * FROM posts ORDER BY created_at DESC LIMIT $1 OFFSET $2", limit, offset)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, posts)
-}
-
-// ----------------- UPDATE -----------------
-func UpdatePostHandler(c *gin.Context) {
-	id := c.Param("id")
-	username := c.GetString("username")
-
-	// Проверяем авторство
-	var authorID int
-	err := database.DB.Get(&authorID, "SELECT author_id FROM posts WHERE id=$1", id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
-		return
-	}
-
-	// Получаем ID пользователя
-	var userID int
-	err = database.DB.Get(&userID, "SELECT id FROM users WHERE username=$1", username)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
-		return
-	}
-
-	// Проверка: либо автор, либо админ
-	role := c.GetString("role")
-	if userID != authorID && role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you can only update your own posts"})
-		return
-	}
-
-	var post Post
-	if err := c.ShouldBindJSON(&post); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	post.UpdatedAt = time.Now()
-	_, err = database.DB.NamedExec(`UPDATE posts SET title=:title, content=:content, updated_at=:updated_at WHERE id=:id`,
-		map[string]interface{}{
-			"title":      post.Title,
-			"content":    post.Content,
-			"updated_at": post.UpdatedAt,
-			"id":         id,
-		})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "post updated"})
-}
-
-// ----------------- DELETE -----------------
-func DeletePostHandler(c *gin.Context) {
-	id := c.Param("id")
-	username := c.GetString("username")
-
-	// Проверяем авторство
-	var authorID int
-	err := database.DB.Get(&authorID, "SELECT author_id FROM posts WHERE id=$1", id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
-		return
-	}
-
-	// Получаем ID пользователя
-	var userID int
-	err = database.DB.Get(&userID, "SELECT id FROM users WHERE username=$1", username)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "user not found"})
-		return
-	}
-
-	role := c.GetString("role")
-	if userID != authorID && role != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "you can only delete your own posts"})
-		return
-	}
-
-	_, err = database.DB.Exec("DELETE FROM posts WHERE id=$1", id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "post deleted"})
-}
+package posts
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/models"
+	"uniconnect/internal/redis"
+	"uniconnect/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CreatePost(c *gin.Context) {
+	var post models.Post
+	if err := c.ShouldBindJSON(&post); err != nil {
+		apperr.Abort(c, apperr.Invalid(err))
+		return
+	}
+
+	post.AuthorID = c.GetInt("user_id")
+	if err := h.Posts.Create(c.Request.Context(), &post); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	if redis.Rdb != nil { // в unit-тестах Redis не подключён
+		redis.Rdb.LPush(c.Request.Context(), "notifications", c.GetString("username")+" created a post: "+post.Title)
+	}
+
+	c.JSON(http.StatusOK, post)
+}
+
+// ----------------- LIST -----------------
+func (h *Handler) ListPosts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset := (page - 1) * limit
+
+	posts, err := h.Posts.List(c.Request.Context(), limit, offset)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, posts)
+}
+
+// ----------------- GET -----------------
+// GetPost отдаёт пост с ETag — его нужно передать в If-Match при редактировании
+func (h *Handler) GetPost(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	post, err := h.Posts.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "post not found"))
+		return
+	}
+
+	c.Header("ETag", etag(post.Version))
+	c.JSON(http.StatusOK, post)
+}
+
+// ----------------- UPDATE -----------------
+// UpdatePost требует If-Match с версией, которую видел клиент: без него 428,
+// если пост с тех пор изменили (например, другой модератор) — 412 и текущий ETag
+func (h *Handler) UpdatePost(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	match := c.GetHeader("If-Match")
+	if match == "" {
+		apperr.Abort(c, apperr.PreconditionRequired("If-Match header with the post ETag is required"))
+		return
+	}
+
+	// Проверяем авторство
+	existing, err := h.Posts.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "post not found"))
+		return
+	}
+
+	// Проверка: либо автор, либо есть право редактировать чужие посты
+	role := c.GetString("role")
+	if c.GetInt("user_id") != existing.AuthorID && !auth.HasPermission(role, auth.PermPostEditAny) {
+		apperr.Abort(c, apperr.Forbidden("you can only update your own posts"))
+		return
+	}
+
+	var post models.Post
+	if err := c.ShouldBindJSON(&post); err != nil {
+		apperr.Abort(c, apperr.Invalid(err))
+		return
+	}
+
+	if !ifMatch(match, existing.Version) {
+		h.abortModified(c, existing.Version)
+		return
+	}
+
+	post.ID = id
+	post.Version = existing.Version
+	err = h.Posts.Update(c.Request.Context(), &post)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		// изменили между чтением и записью
+		if current, err := h.Posts.Get(c.Request.Context(), id); err == nil {
+			h.abortModified(c, current.Version)
+			return
+		}
+	}
+	if err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "post not found"))
+		return
+	}
+
+	c.Header("ETag", etag(post.Version))
+	c.JSON(http.StatusOK, gin.H{"message": "post updated", "version": post.Version})
+}
+
+// abortModified — 412: пост изменился; клиент получает текущий ETag, чтобы перечитать пост
+func (h *Handler) abortModified(c *gin.Context, version int) {
+	c.Header("ETag", etag(version))
+	apperr.Abort(c, apperr.PreconditionFailed("post was modified by someone else, reload it and retry").
+		WithDetails(gin.H{"current_version": version}))
+}
+
+// ----------------- DELETE -----------------
+func (h *Handler) DeletePost(c *gin.Context) {
+	id, ok := paramID(c, "id")
+	if !ok {
+		return
+	}
+
+	// Проверяем авторство
+	existing, err := h.Posts.Get(c.Request.Context(), id)
+	if err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "post not found"))
+		return
+	}
+
+	role := c.GetString("role")
+	if c.GetInt("user_id") != existing.AuthorID && !auth.HasPermission(role, auth.PermPostDeleteAny) {
+		apperr.Abort(c, apperr.Forbidden("you can only delete your own posts"))
+		return
+	}
+
+	if err := h.Posts.Delete(c.Request.Context(), id); err != nil {
+		apperr.Abort(c, apperr.OrNotFound(err, "post not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "post deleted"})
+}
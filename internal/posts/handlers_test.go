@@ -0,0 +1,172 @@
+package posts
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	m.Run()
+}
+
+func newTestHandler() *Handler {
+	posts := memory.NewPostRepo()
+	return NewHandler(posts, memory.NewCommentRepo(posts), memory.NewLikeRepo(posts))
+}
+
+// serve выполняет запрос от имени пользователя userID с ролью role (как после AuthMiddleware)
+func serve(h *Handler, userID int, role, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(apperr.Middleware(), func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("role", role)
+	})
+	r.POST("/posts", h.CreatePost)
+	r.GET("/posts/:id", h.GetPost)
+	r.PUT("/posts/:id", h.UpdatePost)
+	r.DELETE("/posts/:id", h.DeletePost)
+	r.POST("/posts/commentary/:postId", h.CreateComment)
+	r.GET("/posts/commentary/:postId", h.ListComments)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func expect(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status %d, want %d: %s", w.Code, status, w.Body)
+	}
+	if code == "" {
+		return
+	}
+	var e struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.Code != code {
+		t.Fatalf("code %q, want %q: %s", e.Code, code, w.Body)
+	}
+}
+
+const postBody = `{"title":"Exam schedule","content":"Room 101","category":"study"}`
+
+func createPost(t *testing.T, h *Handler, author int) models.Post {
+	t.Helper()
+	w := serve(h, author, auth.RoleStudent, http.MethodPost, "/posts", postBody)
+	expect(t, w, http.StatusOK, "")
+	var p models.Post
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestCreatePost(t *testing.T) {
+	h := newTestHandler()
+	p := createPost(t, h, 1)
+	if p.ID == 0 || p.AuthorID != 1 || p.Version != 1 {
+		t.Fatalf("created post %+v", p)
+	}
+
+	w := serve(h, 1, auth.RoleStudent, http.MethodPost, "/posts", `{"title":"no category"}`)
+	expect(t, w, http.StatusUnprocessableEntity, apperr.CodeValidation)
+}
+
+func TestGetPost(t *testing.T) {
+	h := newTestHandler()
+	createPost(t, h, 1)
+
+	w := serve(h, 2, auth.RoleStudent, http.MethodGet, "/posts/1", "")
+	expect(t, w, http.StatusOK, "")
+	if got := w.Header().Get("ETag"); got != `"1"` {
+		t.Fatalf("ETag %s", got)
+	}
+
+	expect(t, serve(h, 2, auth.RoleStudent, http.MethodGet, "/posts/99", ""), http.StatusNotFound, apperr.CodeNotFound)
+	expect(t, serve(h, 2, auth.RoleStudent, http.MethodGet, "/posts/abc", ""), http.StatusBadRequest, apperr.CodeBadRequest)
+}
+
+func TestUpdatePost(t *testing.T) {
+	h := newTestHandler()
+	createPost(t, h, 1)
+
+	// чужой пост студент менять не может, модератор — может
+	expect(t, serve(h, 2, auth.RoleStudent, http.MethodPut, "/posts/1", postBody, "If-Match", `"1"`),
+		http.StatusForbidden, apperr.CodeForbidden)
+	expect(t, serve(h, 2, auth.RoleStudent, http.MethodPut, "/posts/99", postBody, "If-Match", `"1"`),
+		http.StatusNotFound, apperr.CodeNotFound)
+
+	expect(t, serve(h, 1, auth.RoleStudent, http.MethodPut, "/posts/1", postBody),
+		http.StatusPreconditionRequired, apperr.CodePreconditionRequired)
+
+	w := serve(h, 3, auth.RoleModerator, http.MethodPut, "/posts/1", postBody, "If-Match", `"1"`)
+	expect(t, w, http.StatusOK, "")
+	if got := w.Header().Get("ETag"); got != `"2"` {
+		t.Fatalf("ETag after update %s", got)
+	}
+
+	// автор правил версию 1 — её уже изменили
+	w = serve(h, 1, auth.RoleStudent, http.MethodPut, "/posts/1", postBody, "If-Match", `"1"`)
+	expect(t, w, http.StatusPreconditionFailed, apperr.CodePreconditionFailed)
+	if got := w.Header().Get("ETag"); got != `"2"` {
+		t.Fatalf("ETag on 412 %s", got)
+	}
+	// слабый ETag для If-Match не подходит (строгое сравнение)
+	expect(t, serve(h, 1, auth.RoleStudent, http.MethodPut, "/posts/1", postBody, "If-Match", `W/"2"`),
+		http.StatusPreconditionFailed, apperr.CodePreconditionFailed)
+	expect(t, serve(h, 1, auth.RoleStudent, http.MethodPut, "/posts/1", postBody, "If-Match", `"1", "2"`),
+		http.StatusOK, "")
+}
+
+func TestDeletePost(t *testing.T) {
+	h := newTestHandler()
+	createPost(t, h, 1)
+	createPost(t, h, 1)
+
+	expect(t, serve(h, 2, auth.RoleStudent, http.MethodDelete, "/posts/1", ""), http.StatusForbidden, apperr.CodeForbidden)
+	expect(t, serve(h, 1, auth.RoleStudent, http.MethodDelete, "/posts/1", ""), http.StatusOK, "")
+	expect(t, serve(h, 1, auth.RoleStudent, http.MethodDelete, "/posts/1", ""), http.StatusNotFound, apperr.CodeNotFound)
+	expect(t, serve(h, 3, auth.RoleModerator, http.MethodDelete, "/posts/2", ""), http.StatusOK, "")
+}
+
+func TestComments(t *testing.T) {
+	h := newTestHandler()
+	createPost(t, h, 1)
+
+	w := serve(h, 2, auth.RoleStudent, http.MethodPost, "/posts/commentary/1", `{"content":"see you there"}`)
+	expect(t, w, http.StatusCreated, "")
+	var cm models.Comment
+	if err := json.Unmarshal(w.Body.Bytes(), &cm); err != nil || cm.AuthorID != 2 || cm.PostID != 1 {
+		t.Fatalf("created comment %+v: %v", cm, err)
+	}
+
+	// к несуществующему посту — нарушение внешнего ключа, как в Postgres
+	expect(t, serve(h, 2, auth.RoleStudent, http.MethodPost, "/posts/commentary/99", `{"content":"?"}`),
+		http.StatusUnprocessableEntity, apperr.CodeValidation)
+	expect(t, serve(h, 2, auth.RoleStudent, http.MethodPost, "/posts/commentary/1", `{}`),
+		http.StatusUnprocessableEntity, apperr.CodeValidation)
+
+	w = serve(h, 3, auth.RoleStudent, http.MethodGet, "/posts/commentary/1", "")
+	expect(t, w, http.StatusOK, "")
+	var list []models.Comment
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 1 {
+		t.Fatalf("comments %s", w.Body)
+	}
+}
@@ -1,18 +1,50 @@
-package posts
-
-import (
-	"time"
-)
-
-// Post структура для поста
-type Post struct {
-	ID         int       `db:"id" json:"id"`
-	Title      string    `db:"title" json:"title"`
-	Content    string    `db:"content" json:"content"`
-	AuthorID   int       `db:"author_id" json:"author_id"`
-	CreatedAt  time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
-	LikesCount int       `db:"likes_count" json:"likes_count"`
-	SavedCount int       `db:"saved_count" json:"saved_count"`
-	Category   string    `db:"category" json:"category" binding:"required"`
-}
+package posts
+
+import (
+	"strconv"
+	"strings"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler — обработчики постов, комментариев, лайков и сохранений.
+// Данные только через репозитории: в сервере Postgres, в тестах repository/memory.
+type Handler struct {
+	Posts    repository.PostRepo
+	Comments repository.CommentRepo
+	Likes    repository.LikeRepo
+}
+
+func NewHandler(posts repository.PostRepo, comments repository.CommentRepo, likes repository.LikeRepo) *Handler {
+	return &Handler{Posts: posts, Comments: comments, Likes: likes}
+}
+
+// paramID разбирает числовой параметр маршрута; при ошибке отвечает 400
+func paramID(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("invalid post ID"))
+		return 0, false
+	}
+	return id, true
+}
+
+// etag — ETag поста по его версии: "3"
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatch проверяет If-Match против текущей версии поста: "3", список через запятую или "*".
+// Сравнение строгое (RFC 9110, 13.1.1): слабый W/"3" не совпадает ни с одной версией.
+func ifMatch(header string, version int) bool {
+	want := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == want {
+			return true
+		}
+	}
+	return false
+}
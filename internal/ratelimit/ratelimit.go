@@ -0,0 +1,169 @@
+// Package ratelimit — ограничение частоты запросов: скользящее окно в Redis,
+// ключ — пользователь (или IP для анонимных запросов).
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/metrics"
+	"uniconnect/internal/redis"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Rule — не больше Limit запросов за Window. Нулевое правило ничего не ограничивает.
+type Rule struct {
+	Name   string // группа маршрутов; часть ключа в Redis и метка метрики
+	Limit  int
+	Window time.Duration
+}
+
+// ParseRule разбирает "30/1m": 30 запросов за минуту
+func ParseRule(name, s string) (Rule, error) {
+	n, window, ok := strings.Cut(s, "/")
+	limit, err := strconv.Atoi(n)
+	if !ok || err != nil || limit <= 0 {
+		return Rule{}, fmt.Errorf("rate limit %s: %q must look like 30/1m", name, s)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d < time.Second {
+		return Rule{}, fmt.Errorf("rate limit %s: window %q must be a duration of at least 1s", name, window)
+	}
+	return Rule{Name: name, Limit: limit, Window: d}, nil
+}
+
+// ParseRules разбирает правила из конфигурации (rate_limit.rules)
+func ParseRules(rules map[string]string) (map[string]Rule, error) {
+	out := make(map[string]Rule, len(rules))
+	for name, s := range rules {
+		r, err := ParseRule(name, s)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = r
+	}
+	return out, nil
+}
+
+// Result — состояние окна после запроса
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration // через сколько освободится место в окне
+}
+
+// slidingWindow хранит время каждого запроса в sorted set: старые отметки удаляются,
+// новая добавляется, только если в окне ещё есть место (отклонённые запросы окно не продлевают).
+// Возвращает {разрешено, запросов в окне, мс до освобождения места}.
+var slidingWindow = goredis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+  redis.call('ZADD', KEYS[1], now, ARGV[4])
+  count = count + 1
+  allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+local reset = 0
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+  reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// seq делает отметки уникальными, если два запроса пришли в одну миллисекунду
+var seq atomic.Uint64
+
+// Allow учитывает запрос с ключом key (например "u:42" или "ip:10.0.0.1").
+// Ошибка Redis возвращается вместе с Allowed=true — решать, пропускать ли запрос, вызывающему.
+func Allow(ctx context.Context, rule Rule, key string) (Result, error) {
+	res := Result{Allowed: true, Limit: rule.Limit, Remaining: rule.Limit}
+	if rule.Limit <= 0 {
+		return res, nil
+	}
+	if redis.Rdb == nil {
+		return res, errors.New("redis is not connected")
+	}
+
+	now := time.Now().UnixMilli()
+	member := fmt.Sprintf("%d-%d", now, seq.Add(1))
+	out, err := slidingWindow.Run(ctx, redis.Rdb,
+		[]string{"rl:" + rule.Name + ":" + key},
+		now, rule.Window.Milliseconds(), rule.Limit, member,
+	).Int64Slice()
+	if err != nil {
+		return res, err
+	}
+
+	res.Allowed = out[0] == 1
+	res.Remaining = max(rule.Limit-int(out[1]), 0)
+	res.Reset = time.Duration(out[2]) * time.Millisecond
+	if !res.Allowed {
+		metrics.RateLimited.WithLabelValues(rule.Name).Inc()
+	}
+	return res, nil
+}
+
+// Key — ключ клиента: пользователь, если он известен (после AuthMiddleware), иначе IP
+func Key(c *gin.Context) string {
+	if id := c.GetInt("user_id"); id != 0 {
+		return "u:" + strconv.Itoa(id)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// Seconds — длительность в целых секундах, с округлением вверх (для Retry-After и т.п.)
+func Seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// Middleware ограничивает запросы к группе маршрутов и отдаёт заголовки
+//
+//	X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset (секунд до освобождения места)
+//
+// При превышении — 429 с Retry-After. Запросы по API-ключу пропускаются: у ключей свой лимит
+// (rate_limit_per_minute). Если Redis недоступен, запросы не блокируются.
+func Middleware(rule Rule) gin.HandlerFunc {
+	if rule.Limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		if _, ok := c.Get("api_key_id"); ok {
+			c.Next()
+			return
+		}
+
+		res, err := Allow(c.Request.Context(), rule, Key(c))
+		if err != nil {
+			c.Next()
+			return
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(Seconds(res.Reset)))
+		if !res.Allowed {
+			c.Header("Retry-After", strconv.Itoa(Seconds(res.Reset)))
+			apperr.Abort(c, apperr.TooManyRequests("rate limit exceeded").WithDetails(gin.H{
+				"limit":  res.Limit,
+				"window": rule.Window.String(),
+			}))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	m.Run()
+}
+
+func useMiniredis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	prev := redis.Rdb
+	redis.Rdb = goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		redis.Rdb.Close()
+		redis.Rdb = prev
+	})
+	return mr
+}
+
+func TestParseRule(t *testing.T) {
+	r, err := ParseRule("posts", "30/1m")
+	if err != nil || r != (Rule{Name: "posts", Limit: 30, Window: time.Minute}) {
+		t.Fatalf("ParseRule: %+v %v", r, err)
+	}
+	for _, bad := range []string{"30", "0/1m", "-1/1m", "x/1m", "30/500ms", "30/minute"} {
+		if _, err := ParseRule("posts", bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+// скользящее окно: сверх лимита — отказ, отказы окно не занимают, старые отметки уходят
+func TestAllowSlidingWindow(t *testing.T) {
+	mr := useMiniredis(t)
+	ctx := context.Background()
+	rule := Rule{Name: "posts", Limit: 3, Window: 300 * time.Millisecond}
+
+	for i := 1; i <= rule.Limit; i++ {
+		res, err := Allow(ctx, rule, "u:1")
+		if err != nil || !res.Allowed || res.Remaining != rule.Limit-i {
+			t.Fatalf("request %d: %+v %v", i, res, err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		res, err := Allow(ctx, rule, "u:1")
+		if err != nil || res.Allowed || res.Remaining != 0 {
+			t.Fatalf("over the limit: %+v %v", res, err)
+		}
+		if res.Reset <= 0 || res.Reset > rule.Window {
+			t.Fatalf("reset %v", res.Reset)
+		}
+	}
+	if n, _ := mr.ZMembers("rl:posts:u:1"); len(n) != rule.Limit {
+		t.Fatalf("window holds %d entries, want %d", len(n), rule.Limit)
+	}
+	if ttl := mr.TTL("rl:posts:u:1"); ttl <= 0 || ttl > rule.Window {
+		t.Fatalf("key ttl %v", ttl)
+	}
+
+	// у другого клиента своё окно
+	if res, _ := Allow(ctx, rule, "u:2"); !res.Allowed {
+		t.Fatal("another key is limited")
+	}
+
+	time.Sleep(rule.Window + 50*time.Millisecond)
+	if res, err := Allow(ctx, rule, "u:1"); err != nil || !res.Allowed || res.Remaining != rule.Limit-1 {
+		t.Fatalf("after the window: %+v %v", res, err)
+	}
+}
+
+func TestAllowWithoutRule(t *testing.T) {
+	if res, err := Allow(context.Background(), Rule{}, "u:1"); err != nil || !res.Allowed {
+		t.Fatalf("empty rule: %+v %v", res, err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.RemoteAddr = "10.0.0.1:1234"
+	if got := Key(c); got != "ip:10.0.0.1" {
+		t.Fatalf("anonymous key %q", got)
+	}
+	c.Set("user_id", 42)
+	if got := Key(c); got != "u:42" {
+		t.Fatalf("user key %q", got)
+	}
+}
+
+// serve выполняет n запросов к маршруту с лимитом; who задаёт контекст (пользователь, API-ключ)
+func serve(rule Rule, who func(c *gin.Context), n int) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(apperr.Middleware(), who)
+	r.GET("/posts", Middleware(rule), func(c *gin.Context) { c.Status(http.StatusOK) })
+	var w *httptest.ResponseRecorder
+	for i := 0; i < n; i++ {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts", nil))
+	}
+	return w
+}
+
+func asUser(id int) func(c *gin.Context) {
+	return func(c *gin.Context) { c.Set("user_id", id) }
+}
+
+func TestMiddleware(t *testing.T) {
+	useMiniredis(t)
+	rule := Rule{Name: "posts", Limit: 2, Window: time.Minute}
+
+	w := serve(rule, asUser(1), 2)
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "2" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("within the limit: %d %v", w.Code, w.Header())
+	}
+	w = serve(rule, asUser(1), 1)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("over the limit: %d %v %s", w.Code, w.Header(), w.Body)
+	}
+	// лимит считается по пользователю, а не по адресу
+	if w := serve(rule, asUser(2), 1); w.Code != http.StatusOK {
+		t.Fatalf("another user: %d", w.Code)
+	}
+}
+
+// у запросов по API-ключу свой лимит — групповой их не касается
+func TestMiddlewareSkipsAPIKeys(t *testing.T) {
+	mr := useMiniredis(t)
+	rule := Rule{Name: "posts", Limit: 1, Window: time.Minute}
+	apiKey := func(c *gin.Context) {
+		c.Set("user_id", 3)
+		c.Set("api_key_id", 9)
+	}
+	w := serve(rule, apiKey, 5)
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Fatalf("status %d, headers %v", w.Code, w.Header())
+	}
+	if mr.Exists("rl:posts:u:3") {
+		t.Fatal("API key request was counted")
+	}
+}
+
+// без Redis запросы не блокируются
+func TestMiddlewareRedisDown(t *testing.T) {
+	mr := useMiniredis(t)
+	redis.Rdb.Close()
+	redis.Rdb = goredis.NewClient(&goredis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	mr.Close()
+	if w := serve(Rule{Name: "posts", Limit: 1, Window: time.Minute}, asUser(1), 3); w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+}
@@ -1,27 +1,41 @@
-package redis
-
-import (
-    "context"
-    "fmt"
-    "github.com/redis/go-redis/v9"
-    "log"
-)
-
-var Rdb *redis.Client
-var Ctx = context.Background()
-
-func Connect(host, password string, port int) {
-    addr := fmt.Sprintf("%s:%d", host, port) // формируем адрес с портом
-    Rdb = redis.NewClient(&redis.Options{
-        Addr:     addr,
-        Password: password,
-        DB:       0,
-    })
-
-    _, err := Rdb.Ping(Ctx).Result()
-    if err != nil {
-        log.Fatal("Redis connection failed:", err)
-    }
-
-    log.Println("Redis connected:", addr)
-}
+package redis
+
+import (
+    "context"
+    "fmt"
+    "github.com/redis/go-redis/extra/redisotel/v9"
+    "github.com/redis/go-redis/v9"
+    "log"
+)
+
+var Rdb *redis.Client
+var Ctx = context.Background() // для вызовов вне HTTP-запроса; в обработчиках — c.Request.Context()
+
+func Connect(host, password string, port int) {
+    addr := fmt.Sprintf("%s:%d", host, port) // формируем адрес с портом
+    Rdb = redis.NewClient(&redis.Options{
+        Addr:     addr,
+        Password: password,
+        DB:       0,
+    })
+
+    // спаны на каждую команду; контекст запроса передаётся в вызов (Rdb.Get(c.Request.Context(), ...))
+    if err := redisotel.InstrumentTracing(Rdb); err != nil {
+        log.Fatal("Redis tracing:", err)
+    }
+
+    _, err := Rdb.Ping(Ctx).Result()
+    if err != nil {
+        log.Fatal("Redis connection failed:", err)
+    }
+
+    log.Println("Redis connected:", addr)
+}
+
+// Close закрывает клиент Redis
+func Close() error {
+    if Rdb == nil {
+        return nil
+    }
+    return Rdb.Close()
+}
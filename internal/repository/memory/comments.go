@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+)
+
+type CommentRepo struct {
+	posts *PostRepo
+
+	mu       sync.Mutex
+	comments []models.Comment
+}
+
+var _ repository.CommentRepo = (*CommentRepo)(nil)
+
+// NewCommentRepo — комментарии к постам из posts (как внешний ключ post_id)
+func NewCommentRepo(posts *PostRepo) *CommentRepo {
+	return &CommentRepo{posts: posts}
+}
+
+func (r *CommentRepo) Create(ctx context.Context, cm *models.Comment) error {
+	if !r.posts.exists(cm.PostID) {
+		return errForeignKeyViolation
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cm.ID = len(r.comments) + 1
+	cm.CreatedAt = time.Now()
+	r.comments = append(r.comments, *cm)
+	return nil
+}
+
+func (r *CommentRepo) ListByPost(ctx context.Context, postID int) ([]models.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := []models.Comment{}
+	for _, cm := range r.comments {
+		if cm.PostID == postID {
+			out = append(out, cm)
+		}
+	}
+	return out, nil
+}
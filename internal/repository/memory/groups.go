@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sync"
+	"time"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+)
+
+type GroupRepo struct {
+	mu       sync.Mutex
+	groups   []models.Group
+	requests []models.JoinRequest
+	nextReq  int
+}
+
+var _ repository.GroupRepo = (*GroupRepo)(nil)
+
+func NewGroupRepo() *GroupRepo {
+	return &GroupRepo{nextReq: 1}
+}
+
+func (r *GroupRepo) Create(ctx context.Context, g *models.Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g.ID = len(r.groups) + 1
+	g.CreatedAt = time.Now()
+	members := []int{}
+	for _, id := range g.Members {
+		if !slices.Contains(members, id) {
+			members = append(members, id)
+		}
+	}
+	slices.Sort(members)
+	g.Members = members
+	r.groups = append(r.groups, *g)
+	return nil
+}
+
+func (r *GroupRepo) Get(ctx context.Context, id int) (models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if g := r.find(id); g != nil {
+		return clone(*g), nil
+	}
+	return models.Group{}, sql.ErrNoRows
+}
+
+func (r *GroupRepo) List(ctx context.Context) ([]models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]models.Group, 0, len(r.groups))
+	for _, g := range r.groups {
+		out = append(out, clone(g))
+	}
+	return out, nil
+}
+
+func (r *GroupRepo) ListForUser(ctx context.Context, userID int) ([]models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := []models.Group{}
+	for _, g := range r.groups {
+		if slices.Contains(g.Members, userID) {
+			out = append(out, clone(g))
+		}
+	}
+	return out, nil
+}
+
+func (r *GroupRepo) CreateJoinRequest(ctx context.Context, req *models.JoinRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.find(req.GroupID) == nil {
+		return errForeignKeyViolation
+	}
+	for _, existing := range r.requests {
+		if existing.GroupID == req.GroupID && existing.UserID == req.UserID {
+			return errUniqueViolation
+		}
+	}
+	req.ID = r.nextReq
+	r.nextReq++
+	req.CreatedAt = time.Now()
+	r.requests = append(r.requests, *req)
+	return nil
+}
+
+func (r *GroupRepo) ListJoinRequests(ctx context.Context) ([]models.JoinRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.JoinRequest{}, r.requests...), nil
+}
+
+func (r *GroupRepo) ApproveJoinRequest(ctx context.Context, id int) (models.JoinRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.findRequest(id)
+	if i < 0 {
+		return models.JoinRequest{}, sql.ErrNoRows
+	}
+	req := r.requests[i]
+	r.requests = slices.Delete(r.requests, i, i+1)
+
+	g := r.find(req.GroupID)
+	if g != nil && !slices.Contains(g.Members, req.UserID) {
+		g.Members = append(g.Members, req.UserID)
+		slices.Sort(g.Members)
+	}
+	return req, nil
+}
+
+func (r *GroupRepo) DeleteJoinRequest(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.findRequest(id)
+	if i < 0 {
+		return sql.ErrNoRows
+	}
+	r.requests = slices.Delete(r.requests, i, i+1)
+	return nil
+}
+
+func (r *GroupRepo) find(id int) *models.Group {
+	for i := range r.groups {
+		if r.groups[i].ID == id {
+			return &r.groups[i]
+		}
+	}
+	return nil
+}
+
+func (r *GroupRepo) findRequest(id int) int {
+	for i, req := range r.requests {
+		if req.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// clone — копия без общего слайса участников
+func clone(g models.Group) models.Group {
+	g.Members = slices.Clone(g.Members)
+	return g
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+)
+
+type LikeRepo struct {
+	posts *PostRepo
+
+	mu    sync.Mutex
+	likes []postUser // в порядке добавления
+	saves []postUser
+}
+
+type postUser struct{ postID, userID int }
+
+var _ repository.LikeRepo = (*LikeRepo)(nil)
+
+// NewLikeRepo — лайки и сохранения постов из posts
+func NewLikeRepo(posts *PostRepo) *LikeRepo {
+	return &LikeRepo{posts: posts}
+}
+
+func (r *LikeRepo) Like(ctx context.Context, postID, userID int) error {
+	return r.add(&r.likes, postUser{postID, userID})
+}
+
+func (r *LikeRepo) Unlike(ctx context.Context, postID, userID int) error {
+	r.remove(&r.likes, postUser{postID, userID})
+	return nil
+}
+
+func (r *LikeRepo) Save(ctx context.Context, postID, userID int) error {
+	return r.add(&r.saves, postUser{postID, userID})
+}
+
+func (r *LikeRepo) Unsave(ctx context.Context, postID, userID int) error {
+	r.remove(&r.saves, postUser{postID, userID})
+	return nil
+}
+
+func (r *LikeRepo) LikedPosts(ctx context.Context, userID int) ([]models.Post, error) {
+	r.mu.Lock()
+	var ids []int
+	for i := len(r.likes) - 1; i >= 0; i-- {
+		if r.likes[i].userID == userID {
+			ids = append(ids, r.likes[i].postID)
+		}
+	}
+	r.mu.Unlock()
+
+	out := []models.Post{}
+	for _, id := range ids {
+		p, err := r.posts.Get(ctx, id)
+		if err != nil {
+			continue
+		}
+		p.LikesCount = r.count(r.likes, id)
+		p.SavedCount = r.count(r.saves, id)
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (r *LikeRepo) add(list *[]postUser, pu postUser) error {
+	if !r.posts.exists(pu.postID) {
+		return errForeignKeyViolation
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, x := range *list {
+		if x == pu {
+			return nil
+		}
+	}
+	*list = append(*list, pu)
+	return nil
+}
+
+func (r *LikeRepo) remove(list *[]postUser, pu postUser) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, x := range *list {
+		if x == pu {
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *LikeRepo) count(list []postUser, postID int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, x := range list {
+		if x.postID == postID {
+			n++
+		}
+	}
+	return n
+}
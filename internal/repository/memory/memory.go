@@ -0,0 +1,13 @@
+// Package memory — реализации репозиториев в памяти для unit-тестов обработчиков.
+// Ошибки повторяют Postgres: sql.ErrNoRows, а нарушения ограничений — *pq.Error
+// с тем же кодом, поэтому apperr отвечает так же, как на настоящей базе.
+package memory
+
+import (
+	"github.com/lib/pq"
+)
+
+var (
+	errUniqueViolation     = &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+	errForeignKeyViolation = &pq.Error{Code: "23503", Message: "violates foreign key constraint"}
+)
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+)
+
+type MessageRepo struct {
+	mu       sync.Mutex
+	messages []models.Message
+}
+
+var _ repository.MessageRepo = (*MessageRepo)(nil)
+
+func NewMessageRepo() *MessageRepo {
+	return &MessageRepo{}
+}
+
+func (r *MessageRepo) Send(ctx context.Context, m *models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m.ID = len(r.messages) + 1
+	m.CreatedAt = time.Now()
+	r.messages = append(r.messages, *m)
+	return nil
+}
+
+func (r *MessageRepo) Chat(ctx context.Context, userA, userB int) ([]models.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := []models.Message{}
+	for _, m := range r.messages {
+		if (m.SenderID == userA && m.ReceiverID == userB) || (m.SenderID == userB && m.ReceiverID == userA) {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+)
+
+type PostRepo struct {
+	mu     sync.Mutex
+	posts  map[int]models.Post
+	nextID int
+}
+
+var _ repository.PostRepo = (*PostRepo)(nil)
+
+func NewPostRepo() *PostRepo {
+	return &PostRepo{posts: map[int]models.Post{}, nextID: 1}
+}
+
+func (r *PostRepo) Create(ctx context.Context, p *models.Post) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p.ID = r.nextID
+	r.nextID++
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	p.Version = 1
+	r.posts[p.ID] = *p
+	return nil
+}
+
+func (r *PostRepo) Get(ctx context.Context, id int) (models.Post, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.posts[id]
+	if !ok {
+		return models.Post{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (r *PostRepo) List(ctx context.Context, limit, offset int) ([]models.Post, error) {
+	all := r.sorted(func(models.Post) bool { return true })
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(all) {
+		offset = len(all)
+	}
+	all = all[offset:]
+	if limit >= 0 && limit < len(all) {
+		all = all[:limit]
+	}
+	return all, nil
+}
+
+func (r *PostRepo) Search(ctx context.Context, category string) ([]models.Post, error) {
+	return r.sorted(func(p models.Post) bool { return category == "" || p.Category == category }), nil
+}
+
+// sorted — посты по фильтру, новые сверху (как ORDER BY created_at DESC)
+func (r *PostRepo) sorted(keep func(models.Post) bool) []models.Post {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := []models.Post{}
+	for _, p := range r.posts {
+		if keep(p) {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out
+}
+
+func (r *PostRepo) Update(ctx context.Context, p *models.Post) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.posts[p.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if existing.Version != p.Version {
+		return repository.ErrVersionConflict
+	}
+	existing.Title = p.Title
+	existing.Content = p.Content
+	existing.Version++
+	existing.UpdatedAt = time.Now()
+	r.posts[p.ID] = existing
+	p.Version = existing.Version
+	p.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+func (r *PostRepo) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.posts[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(r.posts, id)
+	return nil
+}
+
+func (r *PostRepo) exists(id int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.posts[id]
+	return ok
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+)
+
+type UserRepo struct {
+	mu    sync.Mutex
+	users []models.User
+}
+
+var _ repository.UserRepo = (*UserRepo)(nil)
+
+// NewUserRepo — репозиторий с уже существующими пользователями (ID назначаются по порядку, если не заданы)
+func NewUserRepo(users ...models.User) *UserRepo {
+	r := &UserRepo{}
+	for _, u := range users {
+		r.add(&u)
+	}
+	return r
+}
+
+func (r *UserRepo) add(u *models.User) {
+	if u.ID == 0 {
+		u.ID = len(r.users) + 1
+	}
+	if u.Role == "" {
+		u.Role = "student"
+	}
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now()
+		u.UpdatedAt = u.CreatedAt
+	}
+	r.users = append(r.users, *u)
+}
+
+func (r *UserRepo) ByID(ctx context.Context, id int) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return models.User{}, sql.ErrNoRows
+}
+
+func (r *UserRepo) ByUsername(ctx context.Context, username string) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return models.User{}, sql.ErrNoRows
+}
+
+func (r *UserRepo) Create(ctx context.Context, u *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if existing.Username == u.Username || existing.Email == u.Email {
+			return errUniqueViolation
+		}
+	}
+	u.ID = 0
+	u.CreatedAt = time.Time{}
+	r.add(u)
+	return nil
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type CommentRepo struct {
+	db *sqlx.DB
+}
+
+var _ repository.CommentRepo = (*CommentRepo)(nil)
+
+func NewCommentRepo(db *sqlx.DB) *CommentRepo {
+	return &CommentRepo{db: db}
+}
+
+func (r *CommentRepo) Create(ctx context.Context, cm *models.Comment) error {
+	return r.db.QueryRowxContext(ctx, `
+		INSERT INTO comments (post_id, author_id, content, created_at)
+		VALUES ($1, $2, $3, now())
+		RETURNING id, created_at
+	`, cm.PostID, cm.AuthorID, cm.Content).Scan(&cm.ID, &cm.CreatedAt)
+}
+
+func (r *CommentRepo) ListByPost(ctx context.Context, postID int) ([]models.Comment, error) {
+	comments := []models.Comment{}
+	// author_id = NULL у комментариев удалённых пользователей
+	err := r.db.SelectContext(ctx, &comments, `
+		SELECT id, post_id, COALESCE(author_id, 0) AS author_id, content, created_at
+		FROM comments WHERE post_id=$1 ORDER BY created_at ASC
+	`, postID)
+	return comments, err
+}
@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type GroupRepo struct {
+	db *sqlx.DB
+}
+
+var _ repository.GroupRepo = (*GroupRepo)(nil)
+
+func NewGroupRepo(db *sqlx.DB) *GroupRepo {
+	return &GroupRepo{db: db}
+}
+
+// groupRow — группа вместе с участниками одним запросом
+type groupRow struct {
+	models.Group
+	MemberIDs pq.Int64Array `db:"member_ids"`
+}
+
+func (g groupRow) group() models.Group {
+	out := g.Group
+	out.Members = make([]int, len(g.MemberIDs))
+	for i, id := range g.MemberIDs {
+		out.Members[i] = int(id)
+	}
+	return out
+}
+
+const selectGroups = `
+	SELECT g.id, g.name, g.created_at,
+	       COALESCE(array_agg(m.user_id ORDER BY m.user_id) FILTER (WHERE m.user_id IS NOT NULL), '{}') AS member_ids
+	FROM groups g
+	LEFT JOIN group_members m ON m.group_id = g.id
+`
+
+func (r *GroupRepo) Create(ctx context.Context, g *models.Group) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowxContext(ctx, `INSERT INTO groups (name) VALUES ($1) RETURNING id, created_at`, g.Name).
+		Scan(&g.ID, &g.CreatedAt)
+	if err != nil {
+		return err
+	}
+	for _, userID := range g.Members {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO group_members (group_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING
+		`, g.ID, userID)
+		if err != nil {
+			return err
+		}
+	}
+	if g.Members == nil {
+		g.Members = []int{}
+	}
+	return tx.Commit()
+}
+
+func (r *GroupRepo) Get(ctx context.Context, id int) (models.Group, error) {
+	var row groupRow
+	err := r.db.GetContext(ctx, &row, selectGroups+` WHERE g.id=$1 GROUP BY g.id`, id)
+	return row.group(), err
+}
+
+func (r *GroupRepo) List(ctx context.Context) ([]models.Group, error) {
+	return r.selectGroups(ctx, selectGroups+` GROUP BY g.id ORDER BY g.id`)
+}
+
+func (r *GroupRepo) ListForUser(ctx context.Context, userID int) ([]models.Group, error) {
+	return r.selectGroups(ctx, selectGroups+`
+		WHERE g.id IN (SELECT group_id FROM group_members WHERE user_id=$1)
+		GROUP BY g.id ORDER BY g.id
+	`, userID)
+}
+
+func (r *GroupRepo) selectGroups(ctx context.Context, query string, args ...interface{}) ([]models.Group, error) {
+	rows := []groupRow{}
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+	out := make([]models.Group, len(rows))
+	for i, row := range rows {
+		out[i] = row.group()
+	}
+	return out, nil
+}
+
+func (r *GroupRepo) CreateJoinRequest(ctx context.Context, req *models.JoinRequest) error {
+	return r.db.QueryRowxContext(ctx, `
+		INSERT INTO group_join_requests (group_id, user_id) VALUES ($1, $2)
+		RETURNING id, created_at
+	`, req.GroupID, req.UserID).Scan(&req.ID, &req.CreatedAt)
+}
+
+func (r *GroupRepo) ListJoinRequests(ctx context.Context) ([]models.JoinRequest, error) {
+	reqs := []models.JoinRequest{}
+	err := r.db.SelectContext(ctx, &reqs, `SELECT * FROM group_join_requests ORDER BY id`)
+	return reqs, err
+}
+
+func (r *GroupRepo) ApproveJoinRequest(ctx context.Context, id int) (models.JoinRequest, error) {
+	var req models.JoinRequest
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return req, err
+	}
+	defer tx.Rollback()
+
+	if err := tx.GetContext(ctx, &req, `DELETE FROM group_join_requests WHERE id=$1 RETURNING *`, id); err != nil {
+		return req, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO group_members (group_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING
+	`, req.GroupID, req.UserID)
+	if err != nil {
+		return req, err
+	}
+	return req, tx.Commit()
+}
+
+func (r *GroupRepo) DeleteJoinRequest(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM group_join_requests WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type LikeRepo struct {
+	db *sqlx.DB
+}
+
+var _ repository.LikeRepo = (*LikeRepo)(nil)
+
+func NewLikeRepo(db *sqlx.DB) *LikeRepo {
+	return &LikeRepo{db: db}
+}
+
+func (r *LikeRepo) Like(ctx context.Context, postID, userID int) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO post_likes(post_id, user_id) VALUES($1, $2) ON CONFLICT(post_id, user_id) DO NOTHING`,
+		postID, userID,
+	)
+	return err
+}
+
+func (r *LikeRepo) Unlike(ctx context.Context, postID, userID int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM post_likes WHERE post_id=$1 AND user_id=$2`, postID, userID)
+	return err
+}
+
+func (r *LikeRepo) Save(ctx context.Context, postID, userID int) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO post_saves(post_id, user_id) VALUES($1, $2) ON CONFLICT(post_id, user_id) DO NOTHING`,
+		postID, userID,
+	)
+	return err
+}
+
+func (r *LikeRepo) Unsave(ctx context.Context, postID, userID int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM post_saves WHERE post_id=$1 AND user_id=$2`, postID, userID)
+	return err
+}
+
+func (r *LikeRepo) LikedPosts(ctx context.Context, userID int) ([]models.Post, error) {
+	posts := []models.Post{}
+	err := r.db.SelectContext(ctx, &posts, `
+		SELECT
+			p.id,
+			p.title,
+			p.content,
+			p.category,
+			p.author_id,
+			p.created_at,
+			p.updated_at,
+			p.version,
+			COALESCE(like_counts.count, 0) AS likes_count,
+			COALESCE(save_counts.count, 0) AS saved_count
+		FROM posts p
+		JOIN post_likes l ON l.post_id = p.id
+		LEFT JOIN (
+			SELECT post_id, COUNT(*) AS count
+			FROM post_likes
+			GROUP BY post_id
+		) AS like_counts ON like_counts.post_id = p.id
+		LEFT JOIN (
+			SELECT post_id, COUNT(*) AS count
+			FROM post_saves
+			GROUP BY post_id
+		) AS save_counts ON save_counts.post_id = p.id
+		WHERE l.user_id = $1
+		ORDER BY l.created_at DESC
+	`, userID)
+	return posts, err
+}
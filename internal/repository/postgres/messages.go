@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type MessageRepo struct {
+	db *sqlx.DB
+}
+
+var _ repository.MessageRepo = (*MessageRepo)(nil)
+
+func NewMessageRepo(db *sqlx.DB) *MessageRepo {
+	return &MessageRepo{db: db}
+}
+
+func (r *MessageRepo) Send(ctx context.Context, m *models.Message) error {
+	return r.db.QueryRowxContext(ctx, `
+		INSERT INTO messages (sender_id, receiver_id, content)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, m.SenderID, m.ReceiverID, m.Content).Scan(&m.ID, &m.CreatedAt)
+}
+
+func (r *MessageRepo) Chat(ctx context.Context, userA, userB int) ([]models.Message, error) {
+	msgs := []models.Message{}
+	err := r.db.SelectContext(ctx, &msgs, `
+		SELECT id, COALESCE(sender_id, 0) AS sender_id, COALESCE(receiver_id, 0) AS receiver_id, content, created_at
+		FROM messages
+		WHERE (sender_id=$1 AND receiver_id=$2) OR (sender_id=$2 AND receiver_id=$1)
+		ORDER BY id
+	`, userA, userB)
+	return msgs, err
+}
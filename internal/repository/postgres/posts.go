@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type PostRepo struct {
+	db *sqlx.DB
+}
+
+var _ repository.PostRepo = (*PostRepo)(nil)
+
+func NewPostRepo(db *sqlx.DB) *PostRepo {
+	return &PostRepo{db: db}
+}
+
+func (r *PostRepo) Create(ctx context.Context, p *models.Post) error {
+	return r.db.QueryRowxContext(ctx, `
+		INSERT INTO posts (title, content, category, author_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, now(), now())
+		RETURNING id, created_at, updated_at, version
+	`, p.Title, p.Content, p.Category, p.AuthorID).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt, &p.Version)
+}
+
+func (r *PostRepo) Get(ctx context.Context, id int) (models.Post, error) {
+	var p models.Post
+	err := r.db.GetContext(ctx, &p, `SELECT * FROM posts WHERE id=$1`, id)
+	return p, err
+}
+
+func (r *PostRepo) List(ctx context.Context, limit, offset int) ([]models.Post, error) {
+	posts := []models.Post{}
+	err := r.db.SelectContext(ctx, &posts, `SELECT * FROM posts ORDER BY created_at DESC LIMIT $1 OFFSET $2`, limit, offset)
+	return posts, err
+}
+
+func (r *PostRepo) Search(ctx context.Context, category string) ([]models.Post, error) {
+	posts := []models.Post{}
+	err := r.db.SelectContext(ctx, &posts, `
+		SELECT *
+		FROM posts
+		WHERE ($1 = '' OR category = $1)
+		ORDER BY created_at DESC
+	`, category)
+	return posts, err
+}
+
+func (r *PostRepo) Update(ctx context.Context, p *models.Post) error {
+	err := r.db.QueryRowxContext(ctx, `
+		UPDATE posts SET title=$1, content=$2, version=version+1, updated_at=now()
+		WHERE id=$3 AND version=$4
+		RETURNING version, updated_at
+	`, p.Title, p.Content, p.ID, p.Version).Scan(&p.Version, &p.UpdatedAt)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// пост удалён или его успели отредактировать
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM posts WHERE id=$1)`, p.ID); err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return repository.ErrVersionConflict
+}
+
+func (r *PostRepo) Delete(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM posts WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// Package postgres — реализации репозиториев поверх sqlx/Postgres
+package postgres
+
+import (
+	"context"
+	"uniconnect/internal/models"
+	"uniconnect/internal/repository"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type UserRepo struct {
+	db *sqlx.DB
+}
+
+var _ repository.UserRepo = (*UserRepo)(nil)
+
+func NewUserRepo(db *sqlx.DB) *UserRepo {
+	return &UserRepo{db: db}
+}
+
+func (r *UserRepo) ByID(ctx context.Context, id int) (models.User, error) {
+	var u models.User
+	err := r.db.GetContext(ctx, &u, `SELECT * FROM users WHERE id=$1`, id)
+	return u, err
+}
+
+func (r *UserRepo) ByUsername(ctx context.Context, username string) (models.User, error) {
+	var u models.User
+	err := r.db.GetContext(ctx, &u, `SELECT * FROM users WHERE username=$1`, username)
+	return u, err
+}
+
+func (r *UserRepo) Create(ctx context.Context, u *models.User) error {
+	// пустая роль — значение по умолчанию из схемы
+	return r.db.QueryRowxContext(ctx, `
+		INSERT INTO users (username, email, password, role)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'student'))
+		RETURNING id, role, created_at, updated_at
+	`, u.Username, u.Email, u.Password, u.Role).Scan(&u.ID, &u.Role, &u.CreatedAt, &u.UpdatedAt)
+}
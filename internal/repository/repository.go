@@ -0,0 +1,69 @@
+// Package repository описывает доступ к данным. Обработчики зависят только от этих
+// интерфейсов: в сервере используются реализации из repository/postgres,
+// в проверках без базы — repository/memory.
+//
+// "Не найдено" во всех реализациях — sql.ErrNoRows (apperr превращает его в 404).
+package repository
+
+import (
+	"context"
+	"errors"
+	"uniconnect/internal/models"
+)
+
+// ErrVersionConflict — запись изменилась после того, как клиент её прочитал
+var ErrVersionConflict = errors.New("version conflict")
+
+type UserRepo interface {
+	ByID(ctx context.Context, id int) (models.User, error)
+	ByUsername(ctx context.Context, username string) (models.User, error)
+	// Create заполняет ID, CreatedAt и UpdatedAt; Password — уже хеш
+	Create(ctx context.Context, u *models.User) error
+}
+
+type PostRepo interface {
+	Create(ctx context.Context, p *models.Post) error
+	Get(ctx context.Context, id int) (models.Post, error)
+	// List — лента, новые сверху
+	List(ctx context.Context, limit, offset int) ([]models.Post, error)
+	// Search — посты категории; пустая категория — все посты
+	Search(ctx context.Context, category string) ([]models.Post, error)
+	// Update меняет title и content, если версия поста всё ещё p.Version, и записывает
+	// в p новую версию; иначе ErrVersionConflict (или sql.ErrNoRows, если поста нет)
+	Update(ctx context.Context, p *models.Post) error
+	Delete(ctx context.Context, id int) error
+}
+
+type CommentRepo interface {
+	Create(ctx context.Context, cm *models.Comment) error
+	ListByPost(ctx context.Context, postID int) ([]models.Comment, error)
+}
+
+// LikeRepo — лайки и сохранения постов. Повторный лайк/сохранение не ошибка.
+type LikeRepo interface {
+	Like(ctx context.Context, postID, userID int) error
+	Unlike(ctx context.Context, postID, userID int) error
+	Save(ctx context.Context, postID, userID int) error
+	Unsave(ctx context.Context, postID, userID int) error
+	// LikedPosts — посты, которые лайкнул пользователь, со счётчиками
+	LikedPosts(ctx context.Context, userID int) ([]models.Post, error)
+}
+
+type MessageRepo interface {
+	Send(ctx context.Context, m *models.Message) error
+	// Chat — переписка двух пользователей по порядку отправки
+	Chat(ctx context.Context, userA, userB int) ([]models.Message, error)
+}
+
+type GroupRepo interface {
+	Create(ctx context.Context, g *models.Group) error
+	Get(ctx context.Context, id int) (models.Group, error)
+	List(ctx context.Context) ([]models.Group, error)
+	ListForUser(ctx context.Context, userID int) ([]models.Group, error)
+
+	CreateJoinRequest(ctx context.Context, r *models.JoinRequest) error
+	ListJoinRequests(ctx context.Context) ([]models.JoinRequest, error)
+	// ApproveJoinRequest добавляет пользователя в группу и удаляет заявку
+	ApproveJoinRequest(ctx context.Context, id int) (models.JoinRequest, error)
+	DeleteJoinRequest(ctx context.Context, id int) error
+}
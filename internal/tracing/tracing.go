@@ -0,0 +1,108 @@
+// Package tracing — OpenTelemetry: спаны HTTP-запросов, SQL и Redis
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"uniconnect/internal/config"
+	"uniconnect/internal/health"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Option меняет настройку провайдера (например, экспортёр в тестах)
+type Option func(*options)
+
+type options struct {
+	exporter sdktrace.SpanExporter
+	sync     bool
+}
+
+// WithExporter подменяет экспортёр из конфигурации. Для проверок удобно
+// передать tracetest.NewInMemoryExporter() — спаны отправляются синхронно.
+func WithExporter(exp sdktrace.SpanExporter) Option {
+	return func(o *options) {
+		o.exporter = exp
+		o.sync = true
+	}
+}
+
+var serviceName = "uniconnect"
+
+// Setup регистрирует глобальный TracerProvider и propagator (W3C traceparent).
+// Возвращает функцию, которая дописывает оставшиеся спаны при остановке.
+func Setup(ctx context.Context, cfg config.TracingConfig, opts ...Option) (func(context.Context) error, error) {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if cfg.ServiceName != "" {
+		serviceName = cfg.ServiceName
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	exp := o.exporter
+	if exp == nil {
+		var err error
+		exp, err = newExporter(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if exp == nil {
+		// экспортёр "none": спаны не пишутся, но traceparent пробрасывается дальше
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(health.Version),
+	)
+	spanProcessor := sdktrace.WithBatcher(exp)
+	if o.sync {
+		spanProcessor = sdktrace.WithSyncer(exp)
+	}
+	tp := sdktrace.NewTracerProvider(
+		spanProcessor,
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		// адрес и заголовки берутся из стандартных OTEL_EXPORTER_OTLP_*, endpoint из конфига важнее
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", cfg.Exporter)
+	}
+}
+
+// Middleware создаёт спан на каждый запрос (имя — шаблон маршрута)
+// и кладёт его в c.Request.Context()
+func Middleware() gin.HandlerFunc {
+	return otelgin.Middleware(serviceName)
+}
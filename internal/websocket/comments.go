@@ -1,57 +1,119 @@
-package websocket
-
-import (
-	"net/http"
-	"strconv"
-	"uniconnect/internal/database"
-
-	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
-)
-
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-}
-
-var connections = make(map[int]map[*websocket.Conn]bool) // postID -> set of connections
-
-func CommentsWS(c *gin.Context) {
-	postIDParam := c.Param("postId")
-	postID, _ := strconv.Atoi(postIDParam)
-
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		return
-	}
-
-	if connections[postID] == nil {
-		connections[postID] = make(map[*websocket.Conn]bool)
-	}
-	connections[postID][conn] = true
-
-	for {
-		var msg struct {
-			AuthorID int    `json:"author_id"`
-			Content  string `json:"content"`
-		}
-		err := conn.ReadJSON(&msg)
-		if err != nil {
-			delete(connections[postID], conn)
-			conn.Close()
-			break
-		}
-
-		// Сохраняем комментарий в БД
-		_, _ = database.DB.Exec(
-			"INSERT INTO comments (post_id, author_id, content) VALUES ($1, $2, $3)",
-			postID, msg.AuthorID, msg.Content,
-		)
-
-		// Отправляем всем подписанным на этот пост
-		for c := range connections[postID] {
-			c.WriteJSON(msg)
-		}
-	}
-}
+package websocket
+
+import (
+	"strconv"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/models"
+	"uniconnect/internal/ratelimit"
+	"uniconnect/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{CheckOrigin: checkOrigin}
+
+// Handler — WebSocket-комнаты комментариев и личных чатов; сообщения сохраняются через репозитории
+type Handler struct {
+	Comments repository.CommentRepo
+	Messages repository.MessageRepo
+
+	// FrameLimit — сколько кадров может прислать один клиент; нулевое — без ограничений
+	FrameLimit ratelimit.Rule
+
+	// CheckSession перепроверяет открытое соединение раз в sessionCheckInterval;
+	// nil — auth.StillAuthorized
+	CheckSession func(c *gin.Context) *apperr.Error
+}
+
+func NewHandler(comments repository.CommentRepo, messages repository.MessageRepo) *Handler {
+	return &Handler{Comments: comments, Messages: messages}
+}
+
+// QueryToken переносит ?access_token= в заголовок Authorization: браузер не может
+// передать заголовки при открытии WebSocket. Ставится перед auth.AuthMiddleware.
+func QueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if t := c.Query("access_token"); t != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+t)
+		}
+		c.Next()
+	}
+}
+
+// CommentsWS — комната комментариев поста; автор кадров — пользователь из токена
+func (h *Handler) CommentsWS(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("postId"))
+	if err != nil {
+		apperr.Abort(c, apperr.BadRequest("invalid post ID"))
+		return
+	}
+	room := strconv.Itoa(postID)
+	userID := c.GetInt("user_id")
+	canWrite := auth.HasAPIScope(c, "comments:write")
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+
+	commentsHub.add(room, conn)
+	stop := h.watchSession(c, commentsHub, conn)
+	defer stop()
+
+	for {
+		var msg struct {
+			AuthorID int    `json:"author_id"`
+			Content  string `json:"content"`
+		}
+		err := conn.ReadJSON(&msg)
+		if err != nil {
+			commentsHub.remove(room, conn)
+			conn.Close()
+			break
+		}
+		if !canWrite {
+			denyFrame(commentsHub, conn, "comments:write")
+			continue
+		}
+		if !h.allowFrame(c, commentsHub, conn) {
+			continue
+		}
+		msg.AuthorID = userID // author_id от клиента не принимаем
+
+		// Сохраняем комментарий в БД
+		_ = h.Comments.Create(c.Request.Context(), &models.Comment{
+			PostID:   postID,
+			AuthorID: msg.AuthorID,
+			Content:  msg.Content,
+		})
+
+		// Отправляем всем подписанным на этот пост
+		commentsHub.broadcast(room, msg)
+	}
+}
+
+// denyFrame отвечает на кадр от API-ключа без права записи; соединение остаётся открытым
+func denyFrame(hub *hub, conn *websocket.Conn, scope string) {
+	hub.send(conn, gin.H{
+		"code":    apperr.CodeForbidden,
+		"message": "API key is missing scope",
+		"details": gin.H{"required": scope},
+	})
+}
+
+// allowFrame проверяет лимит кадров пользователя (ratelimit.Key — по user_id из токена). Кадр сверх лимита не сохраняется и не рассылается,
+// отправитель получает ошибку в формате API; соединение остаётся открытым.
+func (h *Handler) allowFrame(c *gin.Context, hub *hub, conn *websocket.Conn) bool {
+	res, err := ratelimit.Allow(c.Request.Context(), h.FrameLimit, ratelimit.Key(c))
+	if err != nil || res.Allowed {
+		return true
+	}
+	hub.send(conn, gin.H{
+		"code":    apperr.CodeRateLimited,
+		"message": "too many messages, slow down",
+		"details": gin.H{"limit": res.Limit, "window": h.FrameLimit.Window.String(), "retry_after": ratelimit.Seconds(res.Reset)},
+	})
+	return false
+}
@@ -0,0 +1,102 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+	"uniconnect/internal/metrics"
+
+	"github.com/gorilla/websocket"
+)
+
+const writeWait = 5 * time.Second
+
+// hub — подключения, сгруппированные по комнатам (пост, чат).
+// Запись в соединение идёт только под mu, поэтому одновременных WriteJSON нет.
+type hub struct {
+	name  string // метка для метрик
+	mu    sync.Mutex
+	rooms map[string]map[*websocket.Conn]bool
+}
+
+func newHub(name string) *hub {
+	return &hub{name: name, rooms: make(map[string]map[*websocket.Conn]bool)}
+}
+
+var (
+	commentsHub = newHub("comments") // postID -> подключения
+	privateHub  = newHub("private")  // chatID -> подключения
+)
+
+func (h *hub) add(room string, conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.rooms[room] == nil {
+		h.rooms[room] = make(map[*websocket.Conn]bool)
+	}
+	if !h.rooms[room][conn] {
+		h.rooms[room][conn] = true
+		metrics.WSConnections.WithLabelValues(h.name).Inc()
+	}
+}
+
+func (h *hub) remove(room string, conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.rooms[room][conn] {
+		return
+	}
+	delete(h.rooms[room], conn)
+	metrics.WSConnections.WithLabelValues(h.name).Dec()
+	if len(h.rooms[room]) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// broadcast отправляет сообщение всем в комнате
+func (h *hub) broadcast(room string, msg interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	metrics.WSBroadcasts.WithLabelValues(h.name).Inc()
+	for conn := range h.rooms[room] {
+		conn.SetWriteDeadline(time.Now().Add(writeWait))
+		conn.WriteJSON(msg)
+	}
+}
+
+// send отправляет сообщение одному подключению (под mu, как и broadcast)
+func (h *hub) send(conn *websocket.Conn, msg interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	conn.WriteJSON(msg)
+}
+
+// closeConn отправляет одному подключению close frame и закрывает его;
+// read-цикл соединения после этого завершится и уберёт его из комнаты
+func (h *hub) closeConn(conn *websocket.Conn, code int, text string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(writeWait))
+	conn.Close()
+}
+
+// closeAll отправляет всем close frame и закрывает соединения.
+// Read-цикл каждого соединения после этого завершится сам и уберёт его из комнаты.
+func (h *hub) closeAll(code int, text string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	msg := websocket.FormatCloseMessage(code, text)
+	for _, conns := range h.rooms {
+		for conn := range conns {
+			conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+			conn.Close()
+		}
+	}
+}
+
+// CloseAll закрывает все WebSocket-подключения (остановка сервера)
+func CloseAll() {
+	for _, h := range []*hub{commentsHub, privateHub} {
+		h.closeAll(websocket.CloseGoingAway, "server shutting down")
+	}
+}
@@ -1,49 +1,66 @@
-package websocket
-
-import (
-	"uniconnect/internal/database"
-
-	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
-)
-
-var connectionsPrivate = make(map[string]map[*websocket.Conn]bool) // chatID -> set
-
-func PrivateWS(c *gin.Context) {
-	chatID := c.Param("chatId") // например "6_7"
-
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
-	if err != nil {
-		return
-	}
-
-	if connectionsPrivate[chatID] == nil {
-		connectionsPrivate[chatID] = make(map[*websocket.Conn]bool)
-	}
-	connectionsPrivate[chatID][conn] = true
-
-	for {
-		var msg struct {
-			SenderID   int    `json:"sender_id"`
-			ReceiverID int    `json:"receiver_id"`
-			Content    string `json:"content"`
-		}
-		err := conn.ReadJSON(&msg)
-		if err != nil {
-			delete(connectionsPrivate[chatID], conn)
-			conn.Close()
-			break
-		}
-
-		// Сохраняем сообщение в БД
-		_, _ = database.DB.Exec(
-			"INSERT INTO messages (sender_id, receiver_id, content) VALUES ($1, $2, $3)",
-			msg.SenderID, msg.ReceiverID, msg.Content,
-		)
-
-		// Отправляем всем в этом чате
-		for c := range connectionsPrivate[chatID] {
-			c.WriteJSON(msg)
-		}
-	}
-}
+package websocket
+
+import (
+	"fmt"
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/messages"
+	"uniconnect/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrivateWS — личный чат; подключиться может только его участник,
+// отправитель — пользователь из токена, получатель — второй участник
+func (h *Handler) PrivateWS(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	peer, err := messages.Peer(c.Param("chatId"), userID) // например "6_7"
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	// "6_7" и "7_6" — один и тот же чат
+	chatID := fmt.Sprintf("%d_%d", min(userID, peer), max(userID, peer))
+	canWrite := auth.HasAPIScope(c, "messages:write")
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+
+	privateHub.add(chatID, conn)
+	stop := h.watchSession(c, privateHub, conn)
+	defer stop()
+
+	for {
+		var msg struct {
+			SenderID   int    `json:"sender_id"`
+			ReceiverID int    `json:"receiver_id"`
+			Content    string `json:"content"`
+		}
+		err := conn.ReadJSON(&msg)
+		if err != nil {
+			privateHub.remove(chatID, conn)
+			conn.Close()
+			break
+		}
+		if !canWrite {
+			denyFrame(privateHub, conn, "messages:write")
+			continue
+		}
+		if !h.allowFrame(c, privateHub, conn) {
+			continue
+		}
+		msg.SenderID, msg.ReceiverID = userID, peer // ID от клиента не принимаем
+
+		// Сохраняем сообщение в БД
+		_ = h.Messages.Send(c.Request.Context(), &models.Message{
+			SenderID:   msg.SenderID,
+			ReceiverID: msg.ReceiverID,
+			Content:    msg.Content,
+		})
+
+		// Отправляем всем в этом чате
+		privateHub.broadcast(chatID, msg)
+	}
+}
@@ -0,0 +1,66 @@
+package websocket
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"uniconnect/internal/auth"
+	"uniconnect/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// allowedOrigins — сайты, с которых браузер может открыть WebSocket (http.allowed_origins)
+var allowedOrigins []string
+
+// sessionCheckInterval — как часто открытое соединение перепроверяет сессию
+var sessionCheckInterval = 30 * time.Second
+
+// Configure применяет настройки пакета
+func Configure(cfg config.HTTPConfig) {
+	allowedOrigins = cfg.AllowedOrigins
+}
+
+// checkOrigin пропускает клиентов без Origin (не браузеры), тот же сайт и сайты из
+// http.allowed_origins: иначе чужая страница открыла бы соединение с токеном пользователя
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host) || slices.Contains(allowedOrigins, origin)
+}
+
+// watchSession закрывает соединение, когда его сессия перестаёт действовать (выход, выход
+// везде, бан, удаление аккаунта, отзыв API-ключа). stop останавливает проверку.
+func (h *Handler) watchSession(c *gin.Context, hub *hub, conn *websocket.Conn) (stop func()) {
+	check := h.CheckSession
+	if check == nil {
+		check = auth.StillAuthorized
+	}
+	cc := c.Copy() // gin.Context нельзя использовать из другой горутины
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(sessionCheckInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				if e := check(cc); e != nil {
+					hub.closeConn(conn, websocket.ClosePolicyViolation, e.Message)
+					return
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
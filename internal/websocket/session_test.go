@@ -0,0 +1,85 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"uniconnect/internal/apperr"
+	"uniconnect/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// newServer поднимает /ws/posts/:postId с пользователем 7; revoked имитирует отозванную сессию
+func newServer(t *testing.T, revoked *atomic.Bool) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	posts := memory.NewPostRepo()
+	h := NewHandler(memory.NewCommentRepo(posts), memory.NewMessageRepo())
+	h.CheckSession = func(c *gin.Context) *apperr.Error {
+		if revoked.Load() {
+			return apperr.Unauthorized("session revoked, please log in again")
+		}
+		return nil
+	}
+	r := gin.New()
+	r.Use(apperr.Middleware(), func(c *gin.Context) { c.Set("user_id", 7) })
+	r.GET("/ws/posts/:postId", h.CommentsWS)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dial(srv *httptest.Server, origin string) (*websocket.Conn, *http.Response, error) {
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/posts/1", header)
+}
+
+func TestCheckOrigin(t *testing.T) {
+	allowedOrigins = []string{"https://app.uni.edu"}
+	t.Cleanup(func() { allowedOrigins = nil })
+	srv := newServer(t, new(atomic.Bool))
+
+	for _, origin := range []string{"", "https://app.uni.edu", srv.URL} {
+		conn, _, err := dial(srv, origin)
+		if err != nil {
+			t.Fatalf("origin %q: %v", origin, err)
+		}
+		conn.Close()
+	}
+	if _, resp, err := dial(srv, "https://evil.example"); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("foreign origin accepted: %v", err)
+	}
+}
+
+// после отзыва сессии открытое соединение закрывается с 1008
+func TestSessionRevokedClosesSocket(t *testing.T) {
+	prev := sessionCheckInterval
+	sessionCheckInterval = 20 * time.Millisecond
+	t.Cleanup(func() { sessionCheckInterval = prev })
+
+	revoked := new(atomic.Bool)
+	conn, _, err := dial(newServer(t, revoked), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	revoked.Store(true)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("read: %v, want close 1008", err)
+	}
+	if ce := err.(*websocket.CloseError); ce.Text != "session revoked, please log in again" {
+		t.Fatalf("close reason %q", ce.Text)
+	}
+}
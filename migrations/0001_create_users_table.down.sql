@@ -1 +1 @@
-DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS users;
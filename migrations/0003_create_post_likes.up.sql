@@ -1,7 +1,7 @@
-CREATE TABLE post_likes (
-    id SERIAL PRIMARY KEY,
-    post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
-    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    created_at TIMESTAMP DEFAULT NOW(),
-    UNIQUE(post_id, user_id)
-);
+CREATE TABLE post_likes (
+    id SERIAL PRIMARY KEY,
+    post_id INT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(post_id, user_id)
+);
@@ -1,4 +1,4 @@
-CREATE TABLE tags (
-  id SERIAL PRIMARY KEY,
-  name TEXT UNIQUE NOT NULL
-);
+CREATE TABLE tags (
+  id SERIAL PRIMARY KEY,
+  name TEXT UNIQUE NOT NULL
+);
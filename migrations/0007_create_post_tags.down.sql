@@ -1,2 +1 @@
-DROP TABLE IF EXISTS post_tags;
-DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS post_tags;
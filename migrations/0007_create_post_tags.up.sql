@@ -1,5 +1,5 @@
-CREATE TABLE post_tags (
-  post_id INT REFERENCES posts(id) ON DELETE CASCADE,
-  tag_id INT REFERENCES tags(id) ON DELETE CASCADE,
-  PRIMARY KEY (post_id, tag_id)
-);
+CREATE TABLE post_tags (
+  post_id INT REFERENCES posts(id) ON DELETE CASCADE,
+  tag_id INT REFERENCES tags(id) ON DELETE CASCADE,
+  PRIMARY KEY (post_id, tag_id)
+);
@@ -1 +1 @@
-DROP TABLE comments;
+DROP TABLE IF EXISTS comments;
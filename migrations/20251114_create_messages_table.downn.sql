@@ -1 +0,0 @@
-DROP TABLE messages;
@@ -1,7 +0,0 @@
-CREATE TABLE messages (
-    id SERIAL PRIMARY KEY,
-    sender_id INT REFERENCES users(id),
-    receiver_id INT REFERENCES users(id),
-    content TEXT NOT NULL,
-    created_at TIMESTAMP DEFAULT NOW()
-);
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN banned_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN banned_until TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN ban_reason TEXT,
+    ADD COLUMN tokens_valid_after TIMESTAMP WITH TIME ZONE;
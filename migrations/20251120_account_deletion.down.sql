@@ -0,0 +1,15 @@
+ALTER TABLE IF EXISTS messages
+    DROP CONSTRAINT IF EXISTS messages_sender_id_fkey,
+    ADD CONSTRAINT messages_sender_id_fkey
+        FOREIGN KEY (sender_id) REFERENCES users(id),
+    DROP CONSTRAINT IF EXISTS messages_receiver_id_fkey,
+    ADD CONSTRAINT messages_receiver_id_fkey
+        FOREIGN KEY (receiver_id) REFERENCES users(id);
+
+ALTER TABLE comments
+    DROP CONSTRAINT IF EXISTS comments_author_id_fkey,
+    ADD CONSTRAINT comments_author_id_fkey
+        FOREIGN KEY (author_id) REFERENCES users(id);
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deleted_at;
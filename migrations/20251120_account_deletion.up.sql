@@ -0,0 +1,16 @@
+ALTER TABLE users
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE comments
+    DROP CONSTRAINT IF EXISTS comments_author_id_fkey,
+    ADD CONSTRAINT comments_author_id_fkey
+        FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- таблица messages есть не во всех окружениях
+ALTER TABLE IF EXISTS messages
+    DROP CONSTRAINT IF EXISTS messages_sender_id_fkey,
+    ADD CONSTRAINT messages_sender_id_fkey
+        FOREIGN KEY (sender_id) REFERENCES users(id) ON DELETE SET NULL,
+    DROP CONSTRAINT IF EXISTS messages_receiver_id_fkey,
+    ADD CONSTRAINT messages_receiver_id_fkey
+        FOREIGN KEY (receiver_id) REFERENCES users(id) ON DELETE SET NULL;
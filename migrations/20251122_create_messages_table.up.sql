@@ -0,0 +1,11 @@
+-- раньше файл назывался .upp.sql и не применялся; в окружениях, где таблицу
+-- создали вручную, она уже есть
+CREATE TABLE IF NOT EXISTS messages (
+    id SERIAL PRIMARY KEY,
+    sender_id INT REFERENCES users(id) ON DELETE SET NULL,
+    receiver_id INT REFERENCES users(id) ON DELETE SET NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_pair ON messages(sender_id, receiver_id);
@@ -0,0 +1,3 @@
+-- версия поста для оптимистичной блокировки: растёт при каждом редактировании, отдаётся как ETag
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,10 @@
+-- false — пароль случайный (аккаунт создан через SSO), пользователь его не знает
+ALTER TABLE users
+    ADD COLUMN password_set BOOLEAN NOT NULL DEFAULT true;
+
+-- аккаунты, созданные входом через SSO: identity появилась вместе с пользователем
+UPDATE users u SET password_set = false
+WHERE EXISTS (
+    SELECT 1 FROM user_identities i
+    WHERE i.user_id = u.id AND i.created_at < u.created_at + interval '1 minute'
+);
@@ -0,0 +1,3 @@
+-- true — группу создал cmd/seed; seed -reset удаляет только такие группы
+ALTER TABLE groups
+    ADD COLUMN seeded BOOLEAN NOT NULL DEFAULT false;
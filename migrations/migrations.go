@@ -0,0 +1,8 @@
+// Package migrations — SQL-миграции схемы, встроенные в бинарники (server, migrate),
+// чтобы они не зависели от каталога запуска.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS